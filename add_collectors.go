@@ -5,5 +5,5 @@ import (
 )
 
 func (app *Application) addCollectors() {
-	app.scheduler.AddTimeIntervalCollector("offers-collector", offers.NewOffersCollector(app.ctx))
-}
\ No newline at end of file
+	app.registry.Add(offers.CollectorName, offers.NewOffersCollector(app.ctx))
+}
@@ -0,0 +1,73 @@
+package alerts
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"mobilda/model"
+	"mobilda/webhooks"
+
+	"bitbucket.org/mobio/go-logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func change(prev, cur model.OfferPayout) model.PayoutChange {
+	prev.OfferId, cur.OfferId = 1, 1
+	return model.PayoutChange{Title: "offer", Previous: &prev, Current: cur}
+}
+
+func TestRules_Check(t *testing.T) {
+	rules := Rules{DropPercent: 20, CurrencyChange: true, BusinessModelChange: true}
+
+	alerts := rules.Check([]model.PayoutChange{
+		{Current: model.OfferPayout{OfferId: 1, Payout: 1}},
+		change(model.OfferPayout{Payout: 2, Currency: "USD"}, model.OfferPayout{Payout: 1.8, Currency: "USD"}),
+		change(model.OfferPayout{Payout: 2, Currency: "USD"}, model.OfferPayout{Payout: 1, Currency: "USD"}),
+		change(model.OfferPayout{Payout: 2, Currency: "USD"}, model.OfferPayout{Payout: 1, Currency: "EUR"}),
+		change(model.OfferPayout{Payout: 2, BusinessModel: "CPI"}, model.OfferPayout{Payout: 2, BusinessModel: "CPA"}),
+	})
+
+	types := []string{}
+	for _, a := range alerts {
+		types = append(types, a.Type)
+	}
+	assert.Equal(t, []string{TypePayoutDrop, TypeCurrencyChange, TypeBusinessModelChange}, types)
+	assert.Equal(t, 50.0, alerts[0].DropPercent)
+
+	assert.Empty(t, Rules{}.Check([]model.PayoutChange{
+		change(model.OfferPayout{Payout: 2, Currency: "USD"}, model.OfferPayout{Payout: 0, Currency: "EUR"}),
+	}))
+}
+
+func TestWebhook_Notify(t *testing.T) {
+	var received struct{ Alerts []Alert }
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		ts, _ := strconv.ParseInt(r.Header.Get(webhooks.TimestampHeader), 10, 64)
+		assert.True(t, webhooks.Verify("secret", ts, body, r.Header.Get(webhooks.SignatureHeader)))
+		assert.Equal(t, EventAlerts, r.Header.Get(webhooks.EventHeader))
+		json.Unmarshal(body, &received)
+	}))
+	defer srv.Close()
+
+	a := New(Config{Rules: Rules{DropPercent: 10}, WebhookUrl: srv.URL, WebhookSecret: "secret"}, logger.NewLogger())
+	alerts := a.Check([]model.PayoutChange{
+		change(model.OfferPayout{Payout: 2}, model.OfferPayout{Payout: 1}),
+		change(model.OfferPayout{Payout: 4}, model.OfferPayout{Payout: 1}),
+	})
+	a.Notify(alerts)
+	a.Close()
+
+	// alerts of runs finishing after shutdown are dropped
+	a.Notify(alerts)
+	a.Close()
+
+	assert.Len(t, alerts, 2)
+	assert.Len(t, received.Alerts, 2)
+	assert.Equal(t, TypePayoutDrop, received.Alerts[0].Type)
+}
@@ -0,0 +1,177 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mobilda/model"
+	"mobilda/webhooks"
+
+	"bitbucket.org/mobio/go-logger"
+	"github.com/sirupsen/logrus"
+)
+
+// EventAlerts is the event header value of webhook alert requests
+const EventAlerts = "alerts"
+
+// Config is the alerts section of app.yaml
+type Config struct {
+	Rules `mapstructure:",squash"`
+	// Mail sends alerts through the logger mail hook
+	Mail          bool   `mapstructure:"mail"`
+	WebhookUrl    string `mapstructure:"webhook_url"`
+	WebhookSecret string `mapstructure:"webhook_secret"`
+}
+
+// Notifier sends alerts
+type Notifier interface {
+	Notify(alerts []Alert) error
+}
+
+// Mail logs the alerts as one error, so the logger mail hook sends a single mail
+type Mail struct {
+	log *logger.Logger
+}
+
+func NewMail(l *logger.Logger) *Mail {
+	return &Mail{log: l}
+}
+
+func (m *Mail) Notify(alerts []Alert) error {
+	lines := make([]string, len(alerts))
+	for i, a := range alerts {
+		lines[i] = a.String()
+	}
+	m.log.WithFields(logrus.Fields{
+		"alerts":  len(alerts),
+		"account": alerts[0].AccountId,
+	}).Error(strings.Join(lines, "\n"))
+	return nil
+}
+
+// Webhook posts alerts as {"alerts": [...]} signed like webhook deliveries
+type Webhook struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func NewWebhook(url, secret string) *Webhook {
+	return &Webhook{url: url, secret: secret, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (h *Webhook) Notify(alerts []Alert) error {
+	body, err := json.Marshal(map[string]interface{}{"alerts": alerts})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhooks.EventHeader, EventAlerts)
+	req.Header.Set(webhooks.TimestampHeader, strconv.FormatInt(timestamp, 10))
+	if h.secret != "" {
+		req.Header.Set(webhooks.SignatureHeader, webhooks.Sign(h.secret, timestamp, body))
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Alerts webhook responded %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// queueSize is the number of alert messages waiting to be sent, messages over it are dropped
+const queueSize = 100
+
+// Alerter checks payout changes and sends alerts to the notifiers in the background,
+// so slow notifiers do not block collectors
+type Alerter struct {
+	rules     Rules
+	notifiers []Notifier
+	log       *logger.Logger
+	queue     chan []Alert
+	done      chan struct{}
+
+	// lock guards queue sends against Close, collectors may still run when the app shuts down
+	lock   sync.Mutex
+	closed bool
+}
+
+// New returns the alerter of the config, alerts are only checked if no notifier is configured
+func New(c Config, l *logger.Logger) *Alerter {
+	a := &Alerter{rules: c.Rules, log: l, queue: make(chan []Alert, queueSize), done: make(chan struct{})}
+	if c.Mail {
+		a.notifiers = append(a.notifiers, NewMail(l))
+	}
+	if c.WebhookUrl != "" {
+		a.notifiers = append(a.notifiers, NewWebhook(c.WebhookUrl, c.WebhookSecret))
+	}
+	go a.loop()
+	return a
+}
+
+// Check returns alerts of the payout changes
+func (a *Alerter) Check(changes []model.PayoutChange) []Alert {
+	return a.rules.Check(changes)
+}
+
+// Notify queues the alerts to be sent to the notifiers as one message
+func (a *Alerter) Notify(alerts []Alert) {
+	if len(alerts) == 0 || len(a.notifiers) == 0 {
+		return
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.closed {
+		a.log.WithField("alerts", len(alerts)).Error("Alerter is closed, alerts are dropped")
+		return
+	}
+	select {
+	case a.queue <- alerts:
+	default:
+		a.log.WithField("alerts", len(alerts)).Error("Alerts queue is full, alerts are dropped")
+	}
+}
+
+// Close sends queued alerts and stops the alerter, alerts notified after it are dropped
+func (a *Alerter) Close() {
+	a.lock.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.lock.Unlock()
+	<-a.done
+}
+
+// loop sends queued alerts, notifier errors are logged
+func (a *Alerter) loop() {
+	defer close(a.done)
+	for alerts := range a.queue {
+		for _, n := range a.notifiers {
+			if err := n.Notify(alerts); err != nil {
+				a.log.WithField("alerts", len(alerts)).Error(err)
+			}
+		}
+	}
+}
+
+func FromContext(ctx context.Context, key string) *Alerter {
+	return ctx.Value(key).(*Alerter)
+}
@@ -0,0 +1,110 @@
+// Package alerts checks payout changes of synced offers against alert rules and sends the alerts
+package alerts
+
+import (
+	"fmt"
+	"strings"
+
+	"mobilda/model"
+)
+
+// Alert types
+const (
+	TypePayoutDrop          = "payout_drop"
+	TypeCurrencyChange      = "currency_change"
+	TypeBusinessModelChange = "business_model_change"
+	TypeFeedAnomaly         = "feed_anomaly"
+)
+
+// Rules are payout alert rules, zero values disable them
+type Rules struct {
+	// DropPercent alerts when payout drops by more than the percent in the same currency
+	DropPercent         float64 `mapstructure:"drop_percent" json:"drop_percent"`
+	CurrencyChange      bool    `mapstructure:"currency_change" json:"currency_change"`
+	BusinessModelChange bool    `mapstructure:"business_model_change" json:"business_model_change"`
+}
+
+// Alert is a payout change matching a rule or an account run with anomalies
+type Alert struct {
+	Type        string             `json:"type"`
+	AccountId   int                `json:"account_id"`
+	OfferId     uint64             `json:"offer_id,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Previous    *model.OfferPayout `json:"previous,omitempty"`
+	Current     *model.OfferPayout `json:"current,omitempty"`
+	DropPercent float64            `json:"drop_percent,omitempty"`
+	RunId       string             `json:"run_id,omitempty"`
+	Action      string             `json:"action,omitempty"`
+	Held        int                `json:"held,omitempty"`
+	Anomalies   []model.RunAnomaly `json:"anomalies,omitempty"`
+}
+
+// NewAnomaly returns the alert of a run with anomalies, held is the number of held changes
+func NewAnomaly(runId string, accountId int, action string, held int, anomalies []model.RunAnomaly) Alert {
+	return Alert{
+		Type:      TypeFeedAnomaly,
+		AccountId: accountId,
+		RunId:     runId,
+		Action:    action,
+		Held:      held,
+		Anomalies: anomalies,
+	}
+}
+
+func (a Alert) String() string {
+	switch a.Type {
+	case TypeFeedAnomaly:
+		checks := []string{}
+		for _, anomaly := range a.Anomalies {
+			check := anomaly.Check
+			if anomaly.Field != "" {
+				check += " of " + anomaly.Field
+			}
+			checks = append(checks, fmt.Sprintf("%s %.2f > %.2f", check, anomaly.Value, anomaly.Threshold))
+		}
+		return fmt.Sprintf("Account %d run %s has anomalies, %d changes held: %s",
+			a.AccountId, a.RunId, a.Held, strings.Join(checks, ", "))
+	case TypePayoutDrop:
+		return fmt.Sprintf("Offer %d of account %d %q payout dropped by %.1f%%: %g -> %g %s",
+			a.OfferId, a.AccountId, a.Title, a.DropPercent, a.Previous.Payout, a.Current.Payout, a.Current.Currency)
+	case TypeCurrencyChange:
+		return fmt.Sprintf("Offer %d of account %d %q currency changed: %g %s -> %g %s",
+			a.OfferId, a.AccountId, a.Title, a.Previous.Payout, a.Previous.Currency, a.Current.Payout, a.Current.Currency)
+	default:
+		return fmt.Sprintf("Offer %d of account %d %q business model changed: %s -> %s",
+			a.OfferId, a.AccountId, a.Title, a.Previous.BusinessModel, a.Current.BusinessModel)
+	}
+}
+
+// Check returns alerts of payout changes, changes of created offers never alert
+func (r Rules) Check(changes []model.PayoutChange) []Alert {
+	alerts := []Alert{}
+	for _, c := range changes {
+		if c.Previous == nil {
+			continue
+		}
+		prev, cur := *c.Previous, c.Current
+		alert := Alert{AccountId: cur.AccountId, OfferId: cur.OfferId, Title: c.Title, Previous: &prev, Current: &cur}
+
+		if prev.Currency != cur.Currency {
+			if r.CurrencyChange {
+				alert.Type = TypeCurrencyChange
+				alerts = append(alerts, alert)
+			}
+		} else if r.DropPercent > 0 && prev.Payout > 0 {
+			drop := (prev.Payout - cur.Payout) / prev.Payout * 100
+			if drop > r.DropPercent {
+				alert.Type = TypePayoutDrop
+				alert.DropPercent = drop
+				alerts = append(alerts, alert)
+			}
+		}
+
+		if r.BusinessModelChange && prev.BusinessModel != cur.BusinessModel {
+			alert.Type = TypeBusinessModelChange
+			alert.DropPercent = 0
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
@@ -0,0 +1,212 @@
+// Package anomaly runs sanity checks of an account sync before its changes are committed
+package anomaly
+
+import (
+	"context"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"mobilda/errors"
+	"mobilda/model"
+)
+
+// Checks
+const (
+	CheckCountDelta   = "count_delta"
+	CheckStoppedRatio = "stopped_ratio"
+	CheckChangedRatio = "changed_ratio"
+)
+
+// fields are offer values compared by the changed field check
+var fields = map[string]func(model.Offer) string{
+	"title":           func(o model.Offer) string { return o.Title },
+	"package_name":    func(o model.Offer) string { return o.PackageName },
+	"preview_url":     func(o model.Offer) string { return o.PreviewUrl },
+	"tracking_domain": func(o model.Offer) string { return host(o.TrackingUrl) },
+	"payout":          func(o model.Offer) string { return strconv.FormatFloat(o.Payout, 'f', -1, 64) },
+	"currency":        func(o model.Offer) string { return o.Currency },
+	"business_model":  func(o model.Offer) string { return o.BusinessModel },
+	"countries":       func(o model.Offer) string { return strings.Join(o.Countries, ",") },
+}
+
+// Config is the anomaly section of app.yaml, zero thresholds disable checks
+type Config struct {
+	// MinOffers skips checks of accounts with fewer active offers
+	MinOffers int `mapstructure:"min_offers"`
+	// MaxCountDelta is the maximum relative change of the number of offers in the feed
+	MaxCountDelta float64 `mapstructure:"max_count_delta"`
+	// MaxStoppedRatio is the maximum share of active offers stopped by a run
+	MaxStoppedRatio float64 `mapstructure:"max_stopped_ratio"`
+	// MaxChangedRatio is the maximum share of active offers with a changed field
+	MaxChangedRatio float64 `mapstructure:"max_changed_ratio"`
+	// Fields are compared by the changed ratio check, all fields if empty
+	Fields []string `mapstructure:"fields"`
+	// Action is hold to hold all changes of the run or partial to hold only the suspect ones
+	Action string `mapstructure:"action"`
+}
+
+// Run is an account sync before commit. Active are offers active before the run by id,
+// Seen is the number of loaded offers. Stopped offers are known only for complete loads.
+// Skipped are ids of offers skipped by ingest rules, their stops are expected and not checked.
+type Run struct {
+	Active   map[uint64]model.Offer
+	Seen     int
+	Skipped  map[uint64]bool
+	Complete bool
+	Batch    *model.OfferBatch
+}
+
+// stopped returns the number of stopped offers which are not skipped
+func (r Run) stopped() int {
+	n := 0
+	for _, offer := range r.Batch.Stopped {
+		if !r.Skipped[offer.Id] {
+			n++
+		}
+	}
+	return n
+}
+
+// Validate checks config action and fields
+func (c Config) Validate() error {
+	if c.Action != "" && c.Action != model.HoldActionHold && c.Action != model.HoldActionPartial {
+		return errors.ErrAnomalyActionUnknown
+	}
+	for _, f := range c.Fields {
+		if _, ok := fields[f]; !ok {
+			return errors.ErrAnomalyFieldUnknown
+		}
+	}
+	return nil
+}
+
+// HoldAction returns the action of breached checks, hold by default
+func (c Config) HoldAction() string {
+	if c.Action == "" {
+		return model.HoldActionHold
+	}
+	return c.Action
+}
+
+// Enabled reports whether any check is enabled
+func (c Config) Enabled() bool {
+	return c.MaxCountDelta > 0 || c.MaxStoppedRatio > 0 || c.MaxChangedRatio > 0
+}
+
+func (c Config) fields() []string {
+	if len(c.Fields) > 0 {
+		return c.Fields
+	}
+	list := []string{}
+	for f := range fields {
+		list = append(list, f)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Check returns breached checks of the run
+func (c Config) Check(r Run) []model.RunAnomaly {
+	anomalies := []model.RunAnomaly{}
+	active := len(r.Active)
+	if active == 0 || active < c.MinOffers {
+		return anomalies
+	}
+
+	if r.Complete && c.MaxCountDelta > 0 {
+		delta := math.Abs(float64(r.Seen+len(r.Skipped)-active)) / float64(active)
+		if delta > c.MaxCountDelta {
+			anomalies = append(anomalies, model.RunAnomaly{Check: CheckCountDelta, Value: delta, Threshold: c.MaxCountDelta})
+		}
+	}
+
+	if r.Complete && c.MaxStoppedRatio > 0 {
+		ratio := float64(r.stopped()) / float64(active)
+		if ratio > c.MaxStoppedRatio {
+			anomalies = append(anomalies, model.RunAnomaly{Check: CheckStoppedRatio, Value: ratio, Threshold: c.MaxStoppedRatio})
+		}
+	}
+
+	if c.MaxChangedRatio > 0 {
+		for _, f := range c.fields() {
+			changed := 0
+			for _, offer := range r.Batch.Updated {
+				if c.changed(r, offer, f) {
+					changed++
+				}
+			}
+			ratio := float64(changed) / float64(active)
+			if ratio > c.MaxChangedRatio {
+				anomalies = append(anomalies, model.RunAnomaly{Check: CheckChangedRatio, Field: f, Value: ratio, Threshold: c.MaxChangedRatio})
+			}
+		}
+	}
+
+	return anomalies
+}
+
+// Split returns changes of the run to apply and to hold. The hold action holds all changes, the partial
+// action holds changes suspect by the breached checks: new offers of a grown feed, stopped offers and
+// updated offers with a changed field.
+func (c Config) Split(r Run, anomalies []model.RunAnomaly) (apply, hold *model.OfferBatch) {
+	if c.HoldAction() != model.HoldActionPartial {
+		return &model.OfferBatch{}, r.Batch
+	}
+
+	holdCreated, holdStopped := false, false
+	changedFields := []string{}
+	for _, a := range anomalies {
+		switch a.Check {
+		case CheckCountDelta:
+			if r.Seen+len(r.Skipped) > len(r.Active) {
+				holdCreated = true
+			} else {
+				holdStopped = true
+			}
+		case CheckStoppedRatio:
+			holdStopped = true
+		case CheckChangedRatio:
+			changedFields = append(changedFields, a.Field)
+		}
+	}
+
+	return r.Batch.Partition(func(offer model.Offer, changeType string) bool {
+		switch changeType {
+		case model.OfferChangeCreated:
+			return holdCreated
+		case model.OfferChangeStopped:
+			return holdStopped && !r.Skipped[offer.Id]
+		}
+		for _, f := range changedFields {
+			if c.changed(r, offer, f) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// changed reports whether the field of the updated offer differs from the active offer
+func (c Config) changed(r Run, offer model.Offer, field string) bool {
+	prev, ok := r.Active[offer.Id]
+	if !ok {
+		return false
+	}
+	value := fields[field]
+	return value(prev) != value(offer)
+}
+
+func host(rawUrl string) string {
+	u, err := url.Parse(strings.TrimSpace(rawUrl))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+func FromContext(ctx context.Context, key string) Config {
+	return ctx.Value(key).(Config)
+}
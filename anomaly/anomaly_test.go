@@ -0,0 +1,76 @@
+package anomaly
+
+import (
+	"testing"
+
+	"mobilda/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRun() Run {
+	active := map[uint64]model.Offer{}
+	for id := uint64(1); id <= 10; id++ {
+		active[id] = model.Offer{Id: id, TrackingUrl: "http://track.old.com/" + string(rune('a'+id))}
+	}
+	return Run{
+		Active:   active,
+		Seen:     5,
+		Complete: true,
+		Batch: &model.OfferBatch{
+			Inserted: []model.Offer{{Id: 11}},
+			Updated: []model.Offer{
+				{Id: 1, TrackingUrl: "http://new.com/a"},
+				{Id: 2, TrackingUrl: "http://www.track.old.com/other"},
+			},
+			Stopped: []model.Offer{{Id: 6}, {Id: 7}, {Id: 8}, {Id: 9}, {Id: 10}},
+		},
+	}
+}
+
+func TestConfig_Check(t *testing.T) {
+	c := Config{MaxCountDelta: 0.3, MaxStoppedRatio: 0.3, MaxChangedRatio: 0.05, Fields: []string{"tracking_domain", "payout"}}
+	anomalies := c.Check(testRun())
+
+	checks := []string{}
+	for _, a := range anomalies {
+		checks = append(checks, a.Check+a.Field)
+	}
+	assert.Equal(t, []string{CheckCountDelta, CheckStoppedRatio, CheckChangedRatio + "tracking_domain"}, checks)
+	assert.Equal(t, 0.5, anomalies[0].Value)
+
+	r := testRun()
+	r.Complete = false
+	assert.Len(t, c.Check(r), 1)
+
+	// offers skipped by ingest rules are expected to stop
+	r = testRun()
+	r.Skipped = map[uint64]bool{6: true, 7: true, 8: true, 9: true, 10: true}
+	assert.Len(t, c.Check(r), 1)
+	_, hold := Config{Action: model.HoldActionPartial}.Split(r, []model.RunAnomaly{{Check: CheckStoppedRatio}})
+	assert.Empty(t, hold.Stopped)
+
+	c.MinOffers = 20
+	assert.Empty(t, c.Check(testRun()))
+}
+
+func TestConfig_Split(t *testing.T) {
+	r := testRun()
+	anomalies := []model.RunAnomaly{{Check: CheckStoppedRatio}, {Check: CheckChangedRatio, Field: "tracking_domain"}}
+
+	apply, hold := Config{}.Split(r, anomalies)
+	assert.Equal(t, 0, apply.Size())
+	assert.Equal(t, r.Batch.Size(), hold.Size())
+
+	apply, hold = Config{Action: model.HoldActionPartial}.Split(r, anomalies)
+	assert.Len(t, apply.Inserted, 1)
+	assert.Equal(t, uint64(2), apply.Updated[0].Id)
+	assert.Equal(t, uint64(1), hold.Updated[0].Id)
+	assert.Len(t, hold.Stopped, 5)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, Config{Action: "partial", Fields: []string{"payout"}}.Validate())
+	assert.Error(t, Config{Action: "drop"}.Validate())
+	assert.Error(t, Config{Fields: []string{"rate"}}.Validate())
+}
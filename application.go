@@ -3,16 +3,31 @@ package mobilda
 import (
 	"context"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"time"
 
+	"mobilda/alerts"
+	"mobilda/anomaly"
 	"mobilda/client"
+	"mobilda/collectors"
 	acc "mobilda/collectors/accounts"
 	"mobilda/consts"
 	"mobilda/errors"
+	"mobilda/events"
+	"mobilda/export"
+	"mobilda/ingest"
+	"mobilda/matching"
 	"mobilda/model"
+	"mobilda/overrides"
+	"mobilda/query"
 	"mobilda/server"
+	"mobilda/sinks"
+	"mobilda/storage"
+	"mobilda/taxonomy"
+	"mobilda/webhooks"
 
 	"bitbucket.org/mobio/go-cache"
 	"bitbucket.org/mobio/go-config"
@@ -34,7 +49,18 @@ type Application struct {
 	logger    *logger.Logger
 	dbmanager *dbmanager.DbManager
 	scheduler *scheduler.Scheduler
+	registry  *collectors.Registry
 	cache     *cache.Cache
+	events    *events.Hub
+	matcher   *matching.Matcher
+	taxonomy  *taxonomy.Taxonomy
+	overrides *overrides.Overrides
+	alerts    *alerts.Alerter
+	anomaly   anomaly.Config
+	ingest    ingest.Rules
+	repo      storage.Repository
+	sink      sinks.Sink
+	webhooks  *webhooks.Dispatcher
 	mobClient *client.MobildaClient
 	accounts  []*model.Account
 
@@ -91,6 +117,51 @@ func (app *Application) init() error {
 		return err
 	}
 
+	//Init offer events hub
+	if err := app.initEvents(); err != nil {
+		return err
+	}
+
+	//Init offers matcher
+	if err := app.initMatcher(); err != nil {
+		return err
+	}
+
+	//Init category taxonomy
+	if err := app.initTaxonomy(); err != nil {
+		return err
+	}
+
+	//Init offer overrides
+	if err := app.initOverrides(); err != nil {
+		return err
+	}
+
+	//Init payout alerts
+	if err := app.initAlerts(); err != nil {
+		return err
+	}
+
+	//Init feed anomaly checks
+	if err := app.initAnomaly(); err != nil {
+		return err
+	}
+
+	//Init ingest rules
+	if err := app.initIngest(); err != nil {
+		return err
+	}
+
+	//Init offer sinks
+	if err := app.initSinks(); err != nil {
+		return err
+	}
+
+	//Init webhooks dispatcher
+	if err := app.initWebhooks(); err != nil {
+		return err
+	}
+
 	//Init scheduler
 	if err := app.initScheduler(); err != nil {
 		return err
@@ -158,8 +229,91 @@ func (app *Application) initCache() error {
 	return nil
 }
 
+func (app *Application) initEvents() error {
+	app.events = events.NewHub()
+	return nil
+}
+
+func (app *Application) initMatcher() error {
+	app.matcher = matching.NewMatcher(func() ([]model.Offer, error) {
+		return query.ActiveOffers(app.dbmanager)
+	})
+	if err := app.matcher.Refresh(); err != nil {
+		app.logger.Error(err)
+	}
+	return nil
+}
+
+func (app *Application) initTaxonomy() error {
+	app.taxonomy = taxonomy.New(app.dbmanager)
+
+	if file := app.config.GetString("taxonomy.bootstrap"); file != "" {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(app.configDir, file)
+		}
+		if err := app.taxonomy.Bootstrap(file); err != nil {
+			return err
+		}
+	}
+
+	return app.taxonomy.Load()
+}
+
+func (app *Application) initOverrides() error {
+	app.overrides = overrides.New(app.dbmanager)
+	return app.overrides.Load()
+}
+
+func (app *Application) initAlerts() error {
+	c := alerts.Config{}
+	if err := app.config.UnmarshalKey(consts.Alerts_Key, &c); err != nil {
+		return err
+	}
+	app.alerts = alerts.New(c, app.logger)
+	return nil
+}
+
+func (app *Application) initAnomaly() error {
+	if err := app.config.UnmarshalKey(consts.Anomaly_Key, &app.anomaly); err != nil {
+		return err
+	}
+	return app.anomaly.Validate()
+}
+
+func (app *Application) initIngest() error {
+	if err := app.config.UnmarshalKey(consts.Ingest_Key, &app.ingest); err != nil {
+		return err
+	}
+	return app.ingest.Validate()
+}
+
+func (app *Application) initSinks() error {
+	configs := []sinks.Config{}
+	if err := app.config.UnmarshalKey(consts.Sinks_Key, &configs); err != nil {
+		return err
+	}
+
+	app.repo = storage.NewPostgres(app.dbmanager)
+	sink, err := sinks.New(configs, app.repo, app.logger)
+	if err != nil {
+		return err
+	}
+	app.sink = sink
+	return nil
+}
+
+func (app *Application) initWebhooks() error {
+	opts := webhooks.DefaultOptions
+	if n := app.config.GetInt("webhooks.max_attempts"); n > 0 {
+		opts.MaxAttempts = n
+	}
+	app.webhooks = webhooks.NewDispatcher(app.dbmanager, app.logger, opts)
+	return nil
+}
+
 func (app *Application) initScheduler() error {
 	app.scheduler = scheduler.NewScheduler(app.logger)
+	app.registry = collectors.NewRegistry(app.scheduler, app.dbmanager, app.logger)
 	return nil
 }
 
@@ -186,9 +340,19 @@ func (app *Application) initContext() error {
 	ctx := context.Background()
 	ctx = context.WithValue(ctx, consts.Logger_Component_Key, app.logger)
 	ctx = context.WithValue(ctx, consts.DbManager_Component_Key, app.dbmanager)
+	ctx = context.WithValue(ctx, consts.Repository_Component_Key, app.repo)
 	ctx = context.WithValue(ctx, consts.Cache_Component_Key, app.cache)
+	ctx = context.WithValue(ctx, consts.Events_Component_Key, app.events)
+	ctx = context.WithValue(ctx, consts.Matcher_Component_Key, app.matcher)
+	ctx = context.WithValue(ctx, consts.Taxonomy_Component_Key, app.taxonomy)
+	ctx = context.WithValue(ctx, consts.Overrides_Component_Key, app.overrides)
+	ctx = context.WithValue(ctx, consts.Alerts_Component_Key, app.alerts)
+	ctx = context.WithValue(ctx, consts.Anomaly_Component_Key, app.anomaly)
+	ctx = context.WithValue(ctx, consts.Ingest_Component_Key, app.ingest)
+	ctx = context.WithValue(ctx, consts.Sink_Component_Key, app.sink)
 	ctx = context.WithValue(ctx, consts.Config_Component_Key, app.config)
 	ctx = context.WithValue(ctx, consts.Scheduler_Component_Key, app.scheduler)
+	ctx = context.WithValue(ctx, consts.Collectors_Component_Key, app.registry)
 	ctx = context.WithValue(ctx, consts.MobildaClient_Component_Key, app.mobClient)
 	ctx = context.WithValue(ctx, consts.Accounts_Key, app.accounts)
 	app.ctx = ctx
@@ -242,15 +406,84 @@ func (app *Application) Run() {
 	//Start scheduler
 	app.scheduler.Start()
 
+	//Start webhooks delivery
+	app.webhooks.Start()
+
 	go app.server.Run(stop)
 
 	<-app.quit
 	stop <- struct{}{}
 	<-stop
+	app.webhooks.Stop()
 	app.shutdown()
 }
 
+// RunCollector runs a single collector once, for the given accounts or all of them, and waits for it.
+// Interrupt cancels the run.
+func (app *Application) RunCollector(name string, accounts []int) error {
+	defer app.shutdown()
+
+	job, err := app.registry.Run(name, accounts)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-job.Done():
+	case <-app.quit:
+		app.logger.Info("Cancelling collector run...")
+		job.Cancel()
+		<-job.Done()
+	}
+
+	return job.Err()
+}
+
+// ExportOffers writes offers matched by the filter query, e.g. "active=true&country=US",
+// to the file in csv, ndjson or parquet format. "-" writes to stdout.
+func (app *Application) ExportOffers(format, path, filterQuery string) error {
+	defer app.shutdown()
+
+	params, err := url.ParseQuery(filterQuery)
+	if err != nil {
+		return err
+	}
+	filter, err := query.ParseOfferFilter(params)
+	if err != nil {
+		return err
+	}
+
+	// the format is checked before an existing file is truncated
+	if err := export.CheckFormat(format); err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if path != "-" {
+		if out, err = os.Create(path); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+
+	w, err := export.NewWriter(format, out)
+	if err != nil {
+		return err
+	}
+
+	total, err := export.Export(app.dbmanager, filter, w)
+	if err != nil {
+		return err
+	}
+	app.logger.Infof("Exported %d offers", total)
+	return nil
+}
+
 func (app *Application) shutdown() {
+	app.alerts.Close()
+	if err := app.sink.Close(); err != nil {
+		app.logger.Error(err)
+	}
 	app.dbmanager.Close()
 	app.logger.Info("PostgreSQL connection closed...")
 }
@@ -1,10 +1,13 @@
 package client
 
 import (
+	"fmt"
 	"reflect"
 	"strconv"
 	"time"
 
+	"mobilda/client/response"
+	"mobilda/errors"
 	"mobilda/model"
 
 	"bitbucket.org/mobio/go-logger"
@@ -19,6 +22,14 @@ type MobildaApiReader struct {
 	client *MobildaClient
 
 	log *logger.Logger
+
+	// OnPage is called after every page loaded from the api
+	OnPage func(summary response.Pagination)
+	// OnReject is called for every offer skipped because of invalid data
+	OnReject func(id string)
+	// OnError is called when a request fails after all retries, failed attempts which are
+	// retried successfully are only logged
+	OnError func(err error)
 }
 
 func NewMobildaApiReader(c *MobildaClient, l *logger.Logger) *MobildaApiReader {
@@ -47,14 +58,24 @@ func (mar *MobildaApiReader) Offers(accountId int, page, limit uint32, stop <-ch
 			}
 			offers, er, err := mar.client.Offers(accountId, l, p)
 			if er != nil || err != nil {
+				if err == nil {
+					err = fmt.Errorf("Mobilda api error %d: %s", er.Error, er.ErrorMessage)
+				}
 				retries--
-				time.Sleep(time.Millisecond * 300)
 				if retries == 0 {
+					mar.log.WithField("collector", "mobilda-offers-collector").Error(err)
+					mar.onError(errors.ErrApiUnavailable)
 					return
 				}
+				mar.log.WithField("collector", "mobilda-offers-collector").
+					Warnf("Mobilda api request of account %d page %d failed, retrying: %s", accountId, p, err)
+				time.Sleep(time.Millisecond * 300)
 				continue
 			}
 			retries = 5
+			if mar.OnPage != nil {
+				mar.OnPage(offers.Summary)
+			}
 		Loop:
 			for _, offer := range offers.Offers {
 				select {
@@ -66,23 +87,28 @@ func (mar *MobildaApiReader) Offers(accountId int, page, limit uint32, stop <-ch
 						mar.log.WithFields(logrus.Fields{
 							"collector": "mobilda-offers-collector",
 						}).Warnf("Mobilda Offer [ID: %s] has invalid ID", offer.Attributes.ID)
+						if mar.OnReject != nil {
+							mar.OnReject(offer.Attributes.ID)
+						}
 						continue Loop
 					}
-					results <- model.Offer{
-						Id:            offer_id,
-						PackageName:   offer.Attributes.PackageName,
-						Title:         offer.Attributes.Title,
-						Description:   offer.Attributes.Description,
-						Domain:        offer.Attributes.Domain,
-						PreviewUrl:    offer.Attributes.PreviewURL,
-						TrackingUrl:   offer.Attributes.TrackingURL,
-						BusinessModel: offer.Attributes.BusinessModel,
-						Rate: func() string {
-							if reflect.ValueOf(offer.Attributes.Rate).Kind() != reflect.String {
-								return strconv.FormatFloat(offer.Attributes.Rate.(float64), 'E', -1, 64)
-							}
-							return offer.Attributes.Rate.(string)
-						}(),
+					rate := func() string {
+						if reflect.ValueOf(offer.Attributes.Rate).Kind() != reflect.String {
+							return strconv.FormatFloat(offer.Attributes.Rate.(float64), 'E', -1, 64)
+						}
+						return offer.Attributes.Rate.(string)
+					}()
+					item := model.Offer{
+						Id:               offer_id,
+						PackageName:      offer.Attributes.PackageName,
+						Title:            offer.Attributes.Title,
+						Description:      offer.Attributes.Description,
+						Domain:           offer.Attributes.Domain,
+						PreviewUrl:       offer.Attributes.PreviewURL,
+						TrackingUrl:      offer.Attributes.TrackingURL,
+						BusinessModel:    offer.Attributes.BusinessModel,
+						Rate:             rate,
+						Payout:           model.ParsePayout(rate),
 						Currency:         offer.Attributes.Currency,
 						Thumbnail:        offer.Attributes.Thumbnail,
 						Countries:        offer.Targeting.Countries,
@@ -108,6 +134,12 @@ func (mar *MobildaApiReader) Offers(accountId int, page, limit uint32, stop <-ch
 						IsActive:         model.OfferStatusActive,
 						StatusChangedAt:  time.Now(),
 					}
+					item.Normalize()
+					select {
+					case results <- item:
+					case <-stop:
+						return
+					}
 				}
 			}
 
@@ -122,3 +154,9 @@ func (mar *MobildaApiReader) Offers(accountId int, page, limit uint32, stop <-ch
 
 	return results
 }
+
+func (mar *MobildaApiReader) onError(err error) {
+	if mar.OnError != nil {
+		mar.OnError(err)
+	}
+}
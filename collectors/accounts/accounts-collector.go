@@ -6,27 +6,27 @@ import (
 
 	"mobilda/consts"
 	"mobilda/model"
+	"mobilda/storage"
 
-	"bitbucket.org/mobio/go-dbmanager"
 	"bitbucket.org/mobio/go-logger"
 )
 
 type AccountsCollector struct {
 	ctx context.Context
 
-	log *logger.Logger
-	db  *dbmanager.DbManager
-	acs []*model.Account
+	log  *logger.Logger
+	repo storage.Repository
+	acs  []*model.Account
 
 	startedAt time.Time
 }
 
 func NewAccountsCollector(ctx context.Context) *AccountsCollector {
 	return &AccountsCollector{
-		ctx: ctx,
-		log: logger.FromContext(ctx, consts.Logger_Component_Key),
-		db:  dbmanager.FromContext(ctx, consts.DbManager_Component_Key),
-		acs: ctx.Value(consts.Accounts_Key).([]*model.Account),
+		ctx:  ctx,
+		log:  logger.FromContext(ctx, consts.Logger_Component_Key),
+		repo: storage.FromContext(ctx, consts.Repository_Component_Key),
+		acs:  ctx.Value(consts.Accounts_Key).([]*model.Account),
 	}
 }
 
@@ -56,7 +56,7 @@ func (this *AccountsCollector) Run() {
 }
 
 func (this *AccountsCollector) bulkInsert(data []model.Account) {
-	if _, err := this.db.Model(&data).OnConflict("(name) DO NOTHING").Insert(); err != nil {
+	if err := this.repo.SaveAccounts(data); err != nil {
 		this.log.WithField("collector", "mobilda-account-collector").Error(err)
 	}
 }
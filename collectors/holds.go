@@ -0,0 +1,19 @@
+package collectors
+
+import "mobilda/model"
+
+// HoldResolver is implemented by collectors holding changes of anomalous runs for manual approval
+type HoldResolver interface {
+	ResolveHold(holdId int64, offers []uint64, approve bool, reason string) (*model.RunHold, error)
+}
+
+// HoldResolver returns the registered collector resolving held runs
+func (r *Registry) HoldResolver() (HoldResolver, bool) {
+	for _, name := range r.Names() {
+		c, _ := r.Get(name)
+		if resolver, ok := c.(HoldResolver); ok {
+			return resolver, true
+		}
+	}
+	return nil, false
+}
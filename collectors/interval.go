@@ -0,0 +1,47 @@
+package collectors
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// intervalUnits are scheduler interval units
+var intervalUnits = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+}
+
+// IntervalError is returned for invalid time intervals
+type IntervalError string
+
+func (e IntervalError) Error() string {
+	return string(e)
+}
+
+// ParseInterval parses scheduler time interval, e.g. "every 30 minutes" or "every hour".
+// N must be a positive integer like the scheduler requires.
+func ParseInterval(interval string) (time.Duration, error) {
+	fields := strings.Fields(strings.ToLower(interval))
+	if len(fields) == 2 {
+		fields = []string{fields[0], "1", fields[1]}
+	}
+	if len(fields) != 3 || fields[0] != "every" {
+		return 0, IntervalError(fmt.Sprintf("Invalid time interval %q, expected \"every [N] <unit>\"", interval))
+	}
+
+	unit, ok := intervalUnits[strings.TrimSuffix(fields[2], "s")]
+	if !ok {
+		return 0, IntervalError(fmt.Sprintf("Invalid time interval %q, unknown unit %q", interval, fields[2]))
+	}
+
+	// Atoi accepts a sign, the scheduler does not
+	n, err := strconv.Atoi(fields[1])
+	if err != nil || n <= 0 || strings.Trim(fields[1], "0123456789") != "" {
+		return 0, IntervalError(fmt.Sprintf("Invalid time interval %q, N must be a positive integer", interval))
+	}
+
+	return time.Duration(n) * unit, nil
+}
@@ -0,0 +1,32 @@
+package collectors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInterval(t *testing.T) {
+	cases := map[string]time.Duration{
+		"every 30 minutes": 30 * time.Minute,
+		"every minute":     time.Minute,
+		"Every 2 Hours":    2 * time.Hour,
+		"every 24 hours":   24 * time.Hour,
+		"every 10 seconds": 10 * time.Second,
+	}
+	for interval, expected := range cases {
+		d, err := ParseInterval(interval)
+		assert.Nil(t, err, interval)
+		assert.Equal(t, expected, d, interval)
+	}
+
+	invalid := []string{
+		"", "30 minutes", "every", "every 0 minutes", "every -1 hours", "every 5 fortnights", "every 1h minutes",
+		"every 1.5 hours", "every .5 minutes", "every +5 minutes", "every 1e2 seconds",
+	}
+	for _, interval := range invalid {
+		_, err := ParseInterval(interval)
+		assert.NotNil(t, err, interval)
+	}
+}
@@ -0,0 +1,240 @@
+package collectors
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mobilda/errors"
+	"mobilda/model"
+)
+
+const (
+	JobStatusRunning   = "running"
+	JobStatusDone      = "done"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+
+	// finished jobs are kept in memory for this period
+	jobRetention = 24 * time.Hour
+)
+
+// JobRunner is implemented by collectors which can run as a cancellable job reporting progress.
+// Job accounts limit the run to a subset of accounts, empty accounts mean all of them.
+type JobRunner interface {
+	// StartJob marks job accounts as running and returns the function running the job, it fails with
+	// ErrCollectorIsRunning if any of them is already running. Check and start are a single step,
+	// so a scheduled run cannot start in between.
+	StartJob(job *Job) (func() error, error)
+}
+
+// Progress of a single account inside a job
+type Progress struct {
+	model.CollectorRun
+	Page       uint32 `json:"page"`
+	TotalPages uint32 `json:"total_pages"`
+}
+
+// ProgressReporter reports progress of a single account of a job
+type ProgressReporter interface {
+	Progress() Progress
+}
+
+// Job is a single triggered collector run
+type Job struct {
+	Id        string
+	Collector string
+	Accounts  []int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	lock       sync.RWMutex
+	status     string
+	err        error
+	startedAt  time.Time
+	finishedAt time.Time
+	accounts   map[int]ProgressReporter
+}
+
+func NewJobId() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func newJob(collector string, accounts []int) *Job {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Job{
+		Id:        NewJobId(),
+		Collector: collector,
+		Accounts:  accounts,
+		ctx:       ctx,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+		status:    JobStatusRunning,
+		startedAt: time.Now(),
+		accounts:  map[int]ProgressReporter{},
+	}
+}
+
+// Context is cancelled when the job is cancelled
+func (j *Job) Context() context.Context {
+	return j.ctx
+}
+
+// Track registers progress reporter of the account
+func (j *Job) Track(accountId int, p ProgressReporter) {
+	j.lock.Lock()
+	j.accounts[accountId] = p
+	j.lock.Unlock()
+}
+
+// Cancel asks the collector to stop the job
+func (j *Job) Cancel() {
+	j.cancel()
+}
+
+// Done is closed when the job is finished
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+func (j *Job) Status() string {
+	j.lock.RLock()
+	defer j.lock.RUnlock()
+	return j.status
+}
+
+func (j *Job) Err() error {
+	j.lock.RLock()
+	defer j.lock.RUnlock()
+	return j.err
+}
+
+// overlaps reports whether both jobs could collect the same account
+func (j *Job) overlaps(collector string, accounts []int) bool {
+	if j.Collector != collector {
+		return false
+	}
+	if len(j.Accounts) == 0 || len(accounts) == 0 {
+		return true
+	}
+	for _, a := range j.Accounts {
+		for _, b := range accounts {
+			if a == b {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (j *Job) finish(err error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	j.finishedAt = time.Now()
+	j.err = err
+	switch {
+	case j.ctx.Err() != nil:
+		j.status = JobStatusCancelled
+	case err != nil:
+		j.status = JobStatusFailed
+	default:
+		j.status = JobStatusDone
+	}
+	j.cancel()
+	close(j.done)
+}
+
+func (j *Job) MarshalJSON() ([]byte, error) {
+	j.lock.RLock()
+	defer j.lock.RUnlock()
+
+	view := struct {
+		Id         string     `json:"id"`
+		Collector  string     `json:"collector"`
+		Accounts   []int      `json:"accounts,omitempty"`
+		Status     string     `json:"status"`
+		Error      string     `json:"error,omitempty"`
+		StartedAt  time.Time  `json:"started_at"`
+		FinishedAt time.Time  `json:"finished_at"`
+		Progress   []Progress `json:"progress"`
+	}{
+		Id:         j.Id,
+		Collector:  j.Collector,
+		Accounts:   j.Accounts,
+		Status:     j.status,
+		StartedAt:  j.startedAt,
+		FinishedAt: j.finishedAt,
+		Progress:   []Progress{},
+	}
+	if j.err != nil {
+		view.Error = j.err.Error()
+	}
+	for _, p := range j.accounts {
+		view.Progress = append(view.Progress, p.Progress())
+	}
+	sort.Slice(view.Progress, func(a, b int) bool {
+		return view.Progress[a].AccountId < view.Progress[b].AccountId
+	})
+
+	return json.Marshal(view)
+}
+
+// ParseAccounts parses distinct account ids, every value could be a comma separated list
+func ParseAccounts(values ...string) ([]int, error) {
+	accounts := []int{}
+	seen := map[int]bool{}
+	for _, value := range values {
+		for _, v := range strings.Split(value, ",") {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				continue
+			}
+			id, err := strconv.Atoi(v)
+			if err != nil || id <= 0 {
+				return nil, fmt.Errorf("Invalid account id %q", v)
+			}
+			if !seen[id] {
+				seen[id] = true
+				accounts = append(accounts, id)
+			}
+		}
+	}
+	return accounts, nil
+}
+
+// FindAccounts returns accounts by ids, all accounts if ids are empty.
+// It fails with ErrAccountNotFound if any id is not configured.
+func FindAccounts(acs []*model.Account, ids []int) ([]*model.Account, error) {
+	if len(ids) == 0 {
+		return acs, nil
+	}
+
+	accounts := []*model.Account{}
+	for _, id := range ids {
+		var found *model.Account
+		for _, acc := range acs {
+			if acc.Id == id {
+				found = acc
+				break
+			}
+		}
+		if found == nil {
+			return nil, errors.ErrAccountNotFound
+		}
+		accounts = append(accounts, found)
+	}
+
+	return accounts, nil
+}
@@ -0,0 +1,55 @@
+package collectors
+
+import (
+	"testing"
+
+	"mobilda/errors"
+	"mobilda/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAccounts(t *testing.T) {
+	accounts, err := ParseAccounts("2", "1, 3", "")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 1, 3}, accounts)
+
+	accounts, err = ParseAccounts("2,2", "2")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2}, accounts)
+
+	accounts, err = ParseAccounts()
+	assert.Nil(t, err)
+	assert.Empty(t, accounts)
+
+	_, err = ParseAccounts("1,abc")
+	assert.NotNil(t, err)
+
+	_, err = ParseAccounts("0")
+	assert.NotNil(t, err)
+}
+
+func TestJob_Overlaps(t *testing.T) {
+	job := newJob("offers-collector", []int{1, 2})
+	assert.True(t, job.overlaps("offers-collector", nil))
+	assert.True(t, job.overlaps("offers-collector", []int{2}))
+	assert.False(t, job.overlaps("offers-collector", []int{3}))
+	assert.False(t, job.overlaps("other-collector", []int{1}))
+
+	job = newJob("offers-collector", nil)
+	assert.True(t, job.overlaps("offers-collector", []int{3}))
+}
+
+func TestFindAccounts(t *testing.T) {
+	acs := []*model.Account{{Id: 1}, {Id: 2}}
+
+	accounts, err := FindAccounts(acs, []int{2})
+	assert.Nil(t, err)
+	assert.Equal(t, []*model.Account{acs[1]}, accounts)
+
+	accounts, _ = FindAccounts(acs, nil)
+	assert.Len(t, accounts, 2)
+
+	_, err = FindAccounts(acs, []int{1, 3})
+	assert.Equal(t, errors.ErrAccountNotFound, err)
+}
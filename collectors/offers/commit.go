@@ -0,0 +1,72 @@
+package offers
+
+import (
+	"sync"
+	"time"
+
+	"mobilda/alerts"
+	"mobilda/model"
+
+	"bitbucket.org/mobio/go-cache"
+)
+
+// publishLock orders hub events by seq. Seq is assigned when the batch is written, so batches of accounts
+// collected at the same time are written and published one at a time, live subscribers never get
+// an event behind the last one they received.
+var publishLock sync.Mutex
+
+// commit writes the batch to the sink.
+// If the batch fails, offers are committed one by one, so a single invalid offer does not block the rest.
+func (this *OffersCollector) commit(b *model.OfferBatch, run *accountRun) {
+	if b.Size() == 0 {
+		return
+	}
+
+	b.NewEvents(time.Now())
+	err := this.write(b)
+	if err == nil {
+		this.committed(b, run)
+		return
+	}
+
+	this.log.WithField("collector", "mobilda-offers-collector").Error(err)
+	if b.Size() == 1 {
+		run.fail(err)
+		return
+	}
+
+	for _, single := range b.Split() {
+		this.commit(single, run)
+	}
+}
+
+// write writes the batch to the sink and publishes its events in seq order
+func (this *OffersCollector) write(b *model.OfferBatch) error {
+	publishLock.Lock()
+	defer publishLock.Unlock()
+
+	if err := this.sink.Write(b); err != nil {
+		return err
+	}
+	this.hub.Publish(b.Events)
+	return nil
+}
+
+// committed updates run counters after the batch is committed
+func (this *OffersCollector) committed(b *model.OfferBatch, run *accountRun) {
+	run.alert(this.published(b)...)
+
+	run.add(&run.run.Inserted, len(b.Inserted))
+	run.add(&run.run.Updated, len(b.Updated))
+	run.add(&run.run.Stopped, len(b.Stopped))
+}
+
+// published updates hash cache and returns payout alerts of the written batch
+func (this *OffersCollector) published(b *model.OfferBatch) []alerts.Alert {
+	for _, list := range [][]model.Offer{b.Inserted, b.Updated, b.Stopped} {
+		for _, offer := range list {
+			this.cache.Set(offer.CacheId(), offer.Hash, cache.NoExpiration)
+		}
+	}
+	return this.alerts.Check(b.Payouts)
+}
@@ -0,0 +1,76 @@
+package offers
+
+import (
+	"mobilda/collectors"
+	"mobilda/model"
+	"mobilda/sinks"
+
+	"github.com/sirupsen/logrus"
+)
+
+var _ collectors.HoldResolver = (*OffersCollector)(nil)
+
+// ResolveHold approves or rejects pending changes of the held run, all pending changes if offers are empty.
+// Approved changes are written by the repository against stored offers, then mirrored to the other sinks
+// and published like synced ones, rejected changes are logged. The account must not be running.
+func (this *OffersCollector) ResolveHold(holdId int64, offers []uint64, approve bool, reason string) (*model.RunHold, error) {
+	hold, err := this.repo.Hold(holdId)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := this.accounts([]int{hold.AccountId})
+	if err != nil {
+		return nil, err
+	}
+	if accounts, err = this.acquire(accounts, false); err != nil {
+		return nil, err
+	}
+	defer this.release(accounts)
+
+	hold, b, err := this.resolveHeld(holdId, offers, approve, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	log := this.log.WithFields(logrus.Fields{
+		"collector": "mobilda-offers-collector",
+		"account":   hold.AccountId,
+		"hold":      hold.Id,
+		"status":    hold.Status,
+	})
+	if !approve {
+		rejected := []uint64{}
+		for _, list := range [][]model.Offer{b.Inserted, b.Updated, b.Stopped} {
+			for _, offer := range list {
+				rejected = append(rejected, offer.Id)
+			}
+		}
+		log.WithField("offers", rejected).
+			Warnf("Mobilda Offers hold %d: %d changes rejected: %s", hold.Id, len(rejected), reason)
+		return hold, nil
+	}
+
+	this.alerts.Notify(this.published(b))
+	if err := this.matcher.Refresh(); err != nil {
+		log.Error(err)
+	}
+	log.Infof("Mobilda Offers hold %d: changes approved, %d applied", hold.Id, b.Size())
+
+	return hold, nil
+}
+
+// resolveHeld resolves held changes in the repository, approved ones are mirrored and published in seq order
+func (this *OffersCollector) resolveHeld(holdId int64, offers []uint64, approve bool, reason string) (*model.RunHold, *model.OfferBatch, error) {
+	publishLock.Lock()
+	defer publishLock.Unlock()
+
+	hold, b, err := this.repo.ResolveHeld(holdId, offers, approve, reason)
+	if err != nil || !approve || b.Size() == 0 {
+		return hold, b, err
+	}
+	if mirror, ok := this.sink.(sinks.Mirror); ok {
+		mirror.Mirror(b)
+	}
+	this.hub.Publish(b.Events)
+	return hold, b, nil
+}
@@ -2,24 +2,39 @@ package offers
 
 import (
 	"context"
-	"encoding/hex"
+	"sort"
 	"sync"
 	"time"
 
+	"mobilda/alerts"
+	"mobilda/anomaly"
 	"mobilda/client"
+	"mobilda/collectors"
 	"mobilda/consts"
+	"mobilda/errors"
+	"mobilda/events"
+	"mobilda/ingest"
+	"mobilda/matching"
 	"mobilda/model"
+	"mobilda/overrides"
+	"mobilda/sinks"
+	"mobilda/storage"
+	"mobilda/taxonomy"
 
 	"bitbucket.org/mobio/go-cache"
 	"bitbucket.org/mobio/go-collector"
 	"bitbucket.org/mobio/go-config"
-	"bitbucket.org/mobio/go-dbmanager"
 	"bitbucket.org/mobio/go-logger"
-	"github.com/cnf/structhash"
+	"github.com/sirupsen/logrus"
 )
 
+const CollectorName = "offers-collector"
+
 var (
-	_    collector.ICollector = (*OffersCollector)(nil)
+	_    collector.ICollector      = (*OffersCollector)(nil)
+	_    collectors.StatusReporter = (*OffersCollector)(nil)
+	_    collectors.JobRunner      = (*OffersCollector)(nil)
+	_    collectors.Schedulable    = (*OffersCollector)(nil)
 	lock sync.RWMutex
 )
 
@@ -27,18 +42,29 @@ type OffersCollector struct {
 	*collector.BaseCollector
 	ctx context.Context
 
-	log    *logger.Logger
-	config *config.Config
-	client *client.MobildaClient
-	db     *dbmanager.DbManager
-	cache  *cache.Cache
-	acs    []*model.Account
+	log       *logger.Logger
+	config    *config.Config
+	client    *client.MobildaClient
+	repo      storage.Repository
+	cache     *cache.Cache
+	hub       *events.Hub
+	matcher   *matching.Matcher
+	taxonomy  *taxonomy.Taxonomy
+	overrides *overrides.Overrides
+	alerts    *alerts.Alerter
+	anomaly   anomaly.Config
+	ingest    ingest.Rules
+	sink      sinks.Sink
+	acs       []*model.Account
 
 	init     sync.Once
 	interval uint64
 
 	statsLock sync.RWMutex
-	isRunning bool
+	running   map[int]bool
+	paused    bool
+	lastRunAt time.Time
+	lastRuns  map[int]model.CollectorRun
 }
 
 func NewOffersCollector(ctx context.Context) *OffersCollector {
@@ -48,9 +74,19 @@ func NewOffersCollector(ctx context.Context) *OffersCollector {
 		log:           logger.FromContext(ctx, consts.Logger_Component_Key),
 		config:        config.FromContext(ctx, consts.Config_Component_Key),
 		client:        client.FromContext(ctx, consts.MobildaClient_Component_Key),
-		db:            dbmanager.FromContext(ctx, consts.DbManager_Component_Key),
+		repo:          storage.FromContext(ctx, consts.Repository_Component_Key),
 		cache:         cache.FromContext(ctx, consts.Cache_Component_Key),
+		hub:           events.FromContext(ctx, consts.Events_Component_Key),
+		matcher:       matching.FromContext(ctx, consts.Matcher_Component_Key),
+		taxonomy:      taxonomy.FromContext(ctx, consts.Taxonomy_Component_Key),
+		overrides:     overrides.FromContext(ctx, consts.Overrides_Component_Key),
+		alerts:        alerts.FromContext(ctx, consts.Alerts_Component_Key),
+		anomaly:       anomaly.FromContext(ctx, consts.Anomaly_Component_Key),
+		ingest:        ingest.FromContext(ctx, consts.Ingest_Component_Key),
+		sink:          sinks.FromContext(ctx, consts.Sink_Component_Key),
 		acs:           ctx.Value(consts.Accounts_Key).([]*model.Account),
+		running:       map[int]bool{},
+		lastRuns:      map[int]model.CollectorRun{},
 	}
 }
 
@@ -69,128 +105,311 @@ func (this *OffersCollector) collectorInit() {
 }
 
 func (this *OffersCollector) Run() {
-	lock.RLock()
-	if this.isRunning {
+	if this.isPaused() {
+		this.log.Infof("Mobilda Offers collector is paused. Skip...")
+		return
+	}
+
+	// a scheduled run skips accounts being collected
+	accounts, err := this.acquire(this.acs, true)
+	if err == errors.ErrCollectorIsRunning {
 		this.log.Warnf("Mobilda Offers collector already running. Exit...")
-		lock.RUnlock()
 		return
 	}
-	lock.RUnlock()
+	defer this.release(accounts)
 
-	lock.Lock()
-	this.isRunning = true
-	lock.Unlock()
-	defer func() {
-		lock.Lock()
-		this.isRunning = false
-		lock.Unlock()
-	}()
-	defer this.UpdateStats()()
+	this.run(context.Background(), accounts, nil)
+}
+
+// StartJob acquires job accounts, or all accounts if the job has none, and returns the function collecting them.
+// Any account already running fails the start. The job is cancelled cooperatively between offers.
+func (this *OffersCollector) StartJob(job *collectors.Job) (func() error, error) {
+	accounts, err := this.accounts(job.Accounts)
+	if err != nil {
+		return nil, err
+	}
+	if accounts, err = this.acquire(accounts, false); err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		defer this.release(accounts)
+		this.run(job.Context(), accounts, job)
+		return nil
+	}, nil
+}
+
+// run collects acquired accounts, stats are updated by runs of all accounts
+func (this *OffersCollector) run(ctx context.Context, accounts []*model.Account, job *collectors.Job) {
+	if job == nil || len(job.Accounts) == 0 {
+		defer this.UpdateStats()()
+	}
 
 	this.init.Do(this.collectorInit)
 
 	var wg sync.WaitGroup
 
-	for _, acc := range this.acs {
+	runId := newRunId()
+	if job != nil {
+		runId = job.Id
+	}
+	for _, acc := range accounts {
 		wg.Add(1)
-		go this.collect(acc, &wg)
+		go this.collect(ctx, acc, runId, job, &wg)
 	}
 	wg.Wait()
+
+	// matching index is rebuilt after every sync
+	if err := this.matcher.Refresh(); err != nil {
+		this.log.WithField("collector", "mobilda-offers-collector").Error(err)
+	}
 }
 
-func (this *OffersCollector) collect(acc *model.Account, wg *sync.WaitGroup) error {
+func (this *OffersCollector) collect(ctx context.Context, acc *model.Account, runId string, job *collectors.Job, wg *sync.WaitGroup) error {
+	defer wg.Done()
+
+	run := this.startRun(runId, acc.Id)
+	defer this.finishRun(run)
+	if job != nil {
+		job.Track(acc.Id, run)
+	}
+
 	reader := client.NewMobildaApiReader(this.client, this.log)
+	reader.OnPage = run.page
+	reader.OnReject = func(string) { run.add(&run.run.Rejected, 1) }
+	reader.OnError = run.fail
 
 	stop := make(chan bool)
 	defer close(stop)
 
-	forInsert := []model.Offer{}
-	loaded := []interface{}{}
+	active, err := this.activeOffers(acc.Id)
+	if err != nil {
+		this.log.WithField("collector", "mobilda-offers-collector").Error(err)
+		run.fail(err)
+		return nil
+	}
+
+	// changes are committed in chunks while the feed is loaded,
+	// unless they are checked for anomalies before they are committed
+	buffered := this.anomaly.Enabled()
+	b := &model.OfferBatch{}
+	loaded := []uint64{}
+	skipped := map[uint64]bool{}
+Loop:
 	for item := range reader.Offers(acc.Id, 1, client.OffersMaxLimit, stop) {
+		select {
+		case <-ctx.Done():
+			run.cancel()
+			break Loop
+		default:
+		}
+
 		item.AccountId = acc.Id
+		run.add(&run.run.Seen, 1)
+		// skipped offers are not loaded, so stored ones are stopped
+		if reason := this.ingest.Skip(item); reason != "" {
+			run.skip(reason)
+			skipped[item.Id] = true
+			continue
+		}
+		this.taxonomy.Apply(&item)
+		// overrides are hashed, so offers are rewritten when their override changes
+		this.overrides.Apply(&item)
 		// check hash cache
-		hash := hex.EncodeToString(structhash.Sha1(item, 1))
+		hash := item.ContentHash()
 
 		loaded = append(loaded, item.Id)
 
-		if _, ok := this.cache.Get(item.CacheId()); !ok {
+		if h, ok := this.cache.Get(item.CacheId()); !ok {
 			item.Hash = hash
-			forInsert = append(forInsert, item)
-			if len(forInsert)%client.OffersMaxLimit == 0 {
-				this.bulkInsert(forInsert)
-				forInsert = []model.Offer{}
-			}
-		} else {
-			if h, _ := this.cache.Get(item.CacheId()); h != hash {
-				item.Hash = hash
-				err := this.db.Update(&item)
-				if err != nil {
-					this.log.Error(err)
-				} else {
-					this.cache.Set(item.CacheId(), hash, cache.NoExpiration)
-				}
-			}
+			b.Inserted = append(b.Inserted, item)
+		} else if h != hash {
+			item.Hash = hash
+			b.Updated = append(b.Updated, item)
+		}
+
+		if !buffered && b.Size() >= client.OffersMaxLimit {
+			disabledOffers(b, active)
+			this.commit(b, run)
+			b = &model.OfferBatch{}
 		}
 	}
 
-	if len(forInsert) > 0 {
-		this.bulkInsert(forInsert)
+	// offers missing from an incomplete load are not stopped
+	complete := run.complete()
+	if complete {
+		b.Stopped = stoppedOffers(active, loaded)
+	} else {
+		this.log.WithField("collector", "mobilda-offers-collector").
+			Warnf("Mobilda Offers account %d load is incomplete, stopped offers are not updated", acc.Id)
 	}
 
-	this.setStoppedStatus(loaded, acc.Id)
+	// offers disabled by an override are stopped after the anomaly checks, they are not upstream stops
+	apply := b
+	if buffered {
+		r := anomaly.Run{Active: active, Seen: len(loaded), Skipped: skipped, Complete: complete, Batch: b}
+		if anomalies := this.anomaly.Check(r); len(anomalies) > 0 {
+			var held *model.OfferBatch
+			apply, held = this.anomaly.Split(r, anomalies)
+			disabledOffers(held, active)
+			// nothing is committed if held changes are not stored, they are found again by the next run
+			if err := this.hold(run, held, active, anomalies); err != nil {
+				this.log.WithField("collector", "mobilda-offers-collector").Error(err)
+				run.fail(err)
+				return nil
+			}
+		} else if complete {
+			// held changes are outdated by a complete run without anomalies,
+			// incomplete runs are not checked for count and stop anomalies
+			if err := this.repo.SupersedeHolds(acc.Id, time.Now()); err != nil {
+				this.log.WithField("collector", "mobilda-offers-collector").Error(err)
+			}
+		}
+	}
+	disabledOffers(apply, active)
 
-	wg.Done()
+	for _, chunk := range apply.Chunks(client.OffersMaxLimit) {
+		this.commit(chunk, run)
+	}
 
 	return nil
 }
 
-func (this *OffersCollector) setStoppedStatus(loaded []interface{}, accountId int) {
-	suspended := []model.Offer{}
-
-	query := this.db.
-		Model(&suspended).
-		Where("is_active = ?", model.OfferStatusActive).
-		Where("account_id = ?", accountId)
-
-	if len(loaded) > 0 {
-		query.WhereIn("offer_id NOT IN (?)", loaded...)
+// activeOffers returns active offers of the account by id
+func (this *OffersCollector) activeOffers(accountId int) (map[uint64]model.Offer, error) {
+	offers, err := this.repo.ActiveOffers(accountId, nil)
+	if err != nil {
+		return nil, err
+	}
+	active := make(map[uint64]model.Offer, len(offers))
+	for _, offer := range offers {
+		active[offer.Id] = offer
 	}
+	return active, nil
+}
 
-	if err := query.Select(); err != nil {
-		this.log.WithField("collector", "mobilda-offers-collector").Error(err)
+// stoppedOffers returns active offers missing from the loaded ones with stopped status, ordered by id
+func stoppedOffers(active map[uint64]model.Offer, loaded []uint64) []model.Offer {
+	seen := make(map[uint64]bool, len(loaded))
+	for _, id := range loaded {
+		seen[id] = true
 	}
 
 	now := time.Now()
-	for _, offer := range suspended {
+	stopped := []model.Offer{}
+	for id, offer := range active {
+		if seen[id] {
+			continue
+		}
 		offer.IsActive = model.OfferStatusStopped
 		offer.StatusChangedAt = now
-		oldHash := offer.Hash
-		offer.Hash = hex.EncodeToString(structhash.Sha1(offer, 1))
-		err := this.db.Update(&offer)
-		if err != nil {
-			this.log.Error(err)
-		} else {
-			this.cache.Set(offer.CacheId(), offer.Hash, cache.NoExpiration)
-			this.cache.Delete(oldHash)
+		offer.Hash = offer.ContentHash()
+		stopped = append(stopped, offer)
+	}
+	sort.Slice(stopped, func(i, j int) bool { return stopped[i].Id < stopped[j].Id })
+	return stopped
+}
+
+// disabledOffers moves updated offers disabled by an override which are active in storage to stopped
+func disabledOffers(b *model.OfferBatch, active map[uint64]model.Offer) {
+	updated := []model.Offer{}
+	for _, offer := range b.Updated {
+		if _, ok := active[offer.Id]; ok && offer.IsActive == model.OfferStatusStopped {
+			b.Stopped = append(b.Stopped, offer)
+			continue
 		}
+		updated = append(updated, offer)
+	}
+	b.Updated = updated
+}
+
+// hold stores changes held because of anomalies for manual approval and raises an alert
+func (this *OffersCollector) hold(run *accountRun, b *model.OfferBatch, active map[uint64]model.Offer, anomalies []model.RunAnomaly) error {
+	hold := &model.RunHold{
+		RunId:     run.run.RunId,
+		AccountId: run.run.AccountId,
+		Action:    this.anomaly.HoldAction(),
+		Status:    model.HoldStatusHeld,
+		Anomalies: anomalies,
+		Changes:   b.Size(),
+		CreatedAt: time.Now(),
 	}
+	if err := this.repo.HoldChanges(hold, b, active); err != nil {
+		return err
+	}
+	run.hold(b.Size())
+
+	alert := alerts.NewAnomaly(hold.RunId, hold.AccountId, hold.Action, b.Size(), anomalies)
+	this.log.WithField("collector", "mobilda-offers-collector").Warn(alert.String())
+	run.alert(alert)
+	return nil
 }
 
-func (this *OffersCollector) bulkInsert(data []model.Offer) {
-	if _, err := this.db.Model(&data).Insert(); err != nil {
+// startRun registers a new run of the account in collector_run table
+func (this *OffersCollector) startRun(runId string, accountId int) *accountRun {
+	run := &accountRun{run: model.CollectorRun{
+		RunId:     runId,
+		Collector: CollectorName,
+		AccountId: accountId,
+		Status:    model.RunStatusRunning,
+		StartedAt: time.Now(),
+	}}
+
+	if err := this.repo.InsertRun(&run.run); err != nil {
 		this.log.WithField("collector", "mobilda-offers-collector").Error(err)
-	} else {
-		for _, item := range data {
-			this.cache.Set(item.CacheId(), item.Hash, cache.NoExpiration)
+	}
+
+	return run
+}
+
+// finishRun stores final counters and status of the account run
+func (this *OffersCollector) finishRun(run *accountRun) {
+	result := run.finish()
+	// alerts of the run are sent as one message
+	this.alerts.Notify(run.alerts)
+
+	this.log.WithFields(logrus.Fields{
+		"collector": "mobilda-offers-collector",
+		"account":   result.AccountId,
+		"status":    result.Status,
+		"pages":     result.Pages,
+		"seen":      result.Seen,
+		"inserted":  result.Inserted,
+		"updated":   result.Updated,
+		"stopped":   result.Stopped,
+		"rejected":  result.Rejected,
+		"skipped":   result.Skipped,
+		"held":      result.Held,
+		"errors":    result.Errors,
+	}).Infof("Mobilda Offers account %d collected", result.AccountId)
+
+	if reasons := run.skipReasons(); len(reasons) > 0 {
+		this.log.WithFields(logrus.Fields{
+			"collector": "mobilda-offers-collector",
+			"account":   result.AccountId,
+			"reasons":   reasons,
+		}).Infof("Mobilda Offers account %d: %d offers skipped by ingest rules", result.AccountId, result.Skipped)
+	}
+
+	this.statsLock.Lock()
+	this.lastRuns[result.AccountId] = result
+	this.statsLock.Unlock()
+
+	if result.Id == 0 {
+		if err := this.repo.InsertRun(&result); err != nil {
+			this.log.WithField("collector", "mobilda-offers-collector").Error(err)
 		}
+		return
+	}
+
+	if err := this.repo.UpdateRun(&result); err != nil {
+		this.log.WithField("collector", "mobilda-offers-collector").Error(err)
 	}
 }
 
 func (this *OffersCollector) initCache() {
-	offers := []model.Offer{}
-	if err := this.db.Model(&offers).
-		Column("offer_id", "account_id", "hash").
-		Select(); err != nil {
+	offers, err := this.repo.OfferHashes()
+	if err != nil {
 		this.log.Error(err)
 		return
 	}
@@ -204,16 +423,135 @@ func (this *OffersCollector) UpdateStats() func() {
 	start := time.Now()
 	this.BaseCollector.UpdateStats(this.TimeInterval(), 1, start)
 
+	this.statsLock.Lock()
+	this.lastRunAt = start
+	this.statsLock.Unlock()
+
 	return func() {
 		this.BaseCollector.StatsSetLastDuration(time.Since(start))
 		this.log.Infof("Load Mobilda Offers... Time elapsed: %s", this.BaseCollector.Stats().LastRunDuration)
 	}
 }
 
+// accounts returns configured accounts by ids, all accounts if ids are empty
+func (this *OffersCollector) accounts(ids []int) ([]*model.Account, error) {
+	return collectors.FindAccounts(this.acs, ids)
+}
+
+// acquire marks accounts as running. With skipBusy accounts already running are skipped,
+// otherwise any running account fails the whole run.
+func (this *OffersCollector) acquire(accounts []*model.Account, skipBusy bool) ([]*model.Account, error) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	// accounts are marked while checked, so an account listed twice is collected once
+	free := []*model.Account{}
+	for _, acc := range accounts {
+		if this.running[acc.Id] {
+			if !skipBusy {
+				for _, acquired := range free {
+					delete(this.running, acquired.Id)
+				}
+				return nil, errors.ErrCollectorIsRunning
+			}
+			this.log.Warnf("Mobilda Offers account %d already running. Skip...", acc.Id)
+			continue
+		}
+		this.running[acc.Id] = true
+		free = append(free, acc)
+	}
+	if len(free) == 0 {
+		return nil, errors.ErrCollectorIsRunning
+	}
+
+	return free, nil
+}
+
+func (this *OffersCollector) release(accounts []*model.Account) {
+	lock.Lock()
+	defer lock.Unlock()
+	for _, acc := range accounts {
+		delete(this.running, acc.Id)
+	}
+}
+
+// IsRunning reports whether any of accounts is being collected, any account if accounts are empty
+func (this *OffersCollector) IsRunning(accounts []int) bool {
+	lock.RLock()
+	defer lock.RUnlock()
+	if len(accounts) == 0 {
+		return len(this.running) > 0
+	}
+	for _, id := range accounts {
+		if this.running[id] {
+			return true
+		}
+	}
+	return false
+}
+
+func (this *OffersCollector) runningAccounts() []int {
+	lock.RLock()
+	defer lock.RUnlock()
+	accounts := []int{}
+	for _, acc := range this.acs {
+		if this.running[acc.Id] {
+			accounts = append(accounts, acc.Id)
+		}
+	}
+	return accounts
+}
+
+// Status returns collector state with the last outcome of every account
+func (this *OffersCollector) Status() collectors.Status {
+	this.statsLock.RLock()
+	defer this.statsLock.RUnlock()
+
+	status := collectors.Status{
+		Interval:        this.TimeInterval(),
+		IsRunning:       this.IsRunning(nil),
+		RunningAccounts: this.runningAccounts(),
+		LastRunAt:       this.lastRunAt,
+		LastRunDuration: this.BaseCollector.Stats().LastRunDuration.String(),
+		NextRunAt:       collectors.NextRun(this.lastRunAt, this.TimeInterval()),
+		Accounts:        []model.CollectorRun{},
+	}
+	for _, acc := range this.acs {
+		if run, ok := this.lastRuns[acc.Id]; ok {
+			status.Accounts = append(status.Accounts, run)
+		}
+	}
+
+	return status
+}
+
+// SetTimeInterval overrides config time interval, empty interval restores config value
+func (this *OffersCollector) SetTimeInterval(interval string) {
+	lock.Lock()
+	this.NewTimeInterval = interval
+	lock.Unlock()
+}
+
+// SetPaused pauses scheduled runs, manual jobs still run
+func (this *OffersCollector) SetPaused(paused bool) {
+	lock.Lock()
+	this.paused = paused
+	lock.Unlock()
+}
+
+func (this *OffersCollector) isPaused() bool {
+	lock.RLock()
+	defer lock.RUnlock()
+	return this.paused
+}
+
 // time interval (seconds)
 func (this *OffersCollector) TimeInterval() string {
-	if this.NewTimeInterval != "" {
-		return this.NewTimeInterval
+	lock.RLock()
+	interval := this.NewTimeInterval
+	lock.RUnlock()
+	if interval != "" {
+		return interval
 	}
 	return this.config.GetString("collector.offers_interval")
 }
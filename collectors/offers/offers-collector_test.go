@@ -0,0 +1,593 @@
+package offers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"mobilda/alerts"
+	"mobilda/anomaly"
+	"mobilda/client"
+	"mobilda/collectors"
+	"mobilda/errors"
+	"mobilda/events"
+	"mobilda/ingest"
+	"mobilda/matching"
+	"mobilda/model"
+	"mobilda/overrides"
+	"mobilda/sinks"
+	"mobilda/storage"
+	"mobilda/taxonomy"
+
+	"bitbucket.org/mobio/go-cache"
+	"bitbucket.org/mobio/go-collector"
+	"bitbucket.org/mobio/go-logger"
+	"github.com/stretchr/testify/assert"
+)
+
+// feed is a Mobilda api stand-in serving a single page of offers, the first failures requests fail
+type feed struct {
+	offers   []map[string]interface{}
+	failures int
+}
+
+func (f *feed) Do(req *http.Request) (*http.Response, error) {
+	if f.failures > 0 {
+		f.failures--
+		return nil, fmt.Errorf("connection reset")
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"summary":  map[string]interface{}{"current_page": 1, "total_pages": 1, "total_rows": len(f.offers)},
+		"products": f.offers,
+	})
+	return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewReader(body))}, nil
+}
+
+func apiOffer(id, title string) map[string]interface{} {
+	return map[string]interface{}{
+		"attributes": map[string]interface{}{"id": id, "title": title, "rate": "1.5"},
+		"targeting":  map[string]interface{}{"countries": []string{"US"}},
+	}
+}
+
+func newTestCollector(f *feed, repo storage.Repository) *OffersCollector {
+	log := logger.NewLogger()
+	acc := &model.Account{Id: 1, Name: "standard", Hash: "hash", FeedId: 1, Url: "http://feed.local"}
+	return &OffersCollector{
+		BaseCollector: collector.NewBaseCollector(),
+		log:           log,
+		client:        client.NewMobildaClient([]*model.Account{acc}, f, time.Second, 1000, log),
+		repo:          repo,
+		cache:         cache.NewCache(),
+		hub:           events.NewHub(),
+		matcher:       matching.NewMatcher(func() ([]model.Offer, error) { return nil, nil }),
+		taxonomy:      taxonomy.New(nil),
+		overrides:     overrides.New(nil),
+		alerts:        alerts.New(alerts.Config{}, log),
+		sink:          sinks.NewRepository(repo),
+		acs:           []*model.Account{acc},
+		running:       map[int]bool{},
+		lastRuns:      map[int]model.CollectorRun{},
+	}
+}
+
+func collectOnce(c *OffersCollector) model.CollectorRun {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	c.collect(context.Background(), c.acs[0], newRunId(), nil, &wg)
+	return c.lastRuns[1]
+}
+
+func TestOffersCollector_Collect(t *testing.T) {
+	repo := storage.NewMemory()
+	f := &feed{offers: []map[string]interface{}{apiOffer("1", "first"), apiOffer("2", "second")}}
+	c := newTestCollector(f, repo)
+
+	run := collectOnce(c)
+	assert.Equal(t, model.RunStatusSuccess, run.Status)
+	assert.Equal(t, 2, run.Inserted)
+
+	// unchanged offers are skipped, changed are updated, missing are stopped
+	f.offers = []map[string]interface{}{apiOffer("1", "first renamed")}
+	run = collectOnce(c)
+	assert.Equal(t, 0, run.Inserted)
+	assert.Equal(t, 1, run.Updated)
+	assert.Equal(t, 1, run.Stopped)
+
+	offer, _ := repo.Offer(1, 2)
+	assert.Equal(t, model.OfferStatusStopped, offer.IsActive)
+
+	f.offers = []map[string]interface{}{apiOffer("1", "first renamed"), apiOffer("2", "second")}
+	run = collectOnce(c)
+	assert.Equal(t, 1, run.Updated)
+	assert.Equal(t, 0, run.Stopped)
+
+	offer, _ = repo.Offer(1, 2)
+	assert.Equal(t, model.OfferStatusActive, offer.IsActive)
+
+	types := []string{}
+	for _, change := range repo.Changes() {
+		types = append(types, change.ChangeType)
+	}
+	assert.Equal(t, []string{
+		model.OfferChangeCreated,
+		model.OfferChangeCreated,
+		model.OfferChangeUpdated,
+		model.OfferChangeStopped,
+		model.OfferChangeReactivated,
+	}, types)
+	assert.Len(t, repo.Runs(), 3)
+
+	// payout series starts with created offers, renames and reactivation keep the payout
+	assert.Len(t, repo.Payouts(), 2)
+}
+
+func TestOffersCollector_RunAlerts(t *testing.T) {
+	requests, received := 0, []alerts.Alert{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := struct{ Alerts []alerts.Alert }{}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests++
+		received = append(received, body.Alerts...)
+	}))
+	defer srv.Close()
+
+	f := &feed{offers: []map[string]interface{}{apiOffer("1", "first"), apiOffer("2", "second")}}
+	c := newTestCollector(f, storage.NewMemory())
+	c.alerts = alerts.New(alerts.Config{Rules: alerts.Rules{DropPercent: 10}, WebhookUrl: srv.URL}, c.log)
+	collectOnce(c)
+
+	for _, offer := range f.offers {
+		offer["attributes"].(map[string]interface{})["rate"] = "0.5"
+	}
+	collectOnce(c)
+	c.alerts.Close()
+
+	// payout drops of the run are sent as one message
+	assert.Equal(t, 1, requests)
+	assert.Len(t, received, 2)
+}
+
+func TestOffersCollector_CollectRetried(t *testing.T) {
+	repo := storage.NewMemory()
+	f := &feed{offers: []map[string]interface{}{apiOffer("1", "first")}, failures: 1}
+	c := newTestCollector(f, repo)
+
+	// a request retried successfully is not a run error
+	run := collectOnce(c)
+	assert.Equal(t, model.RunStatusSuccess, run.Status)
+	assert.Equal(t, 0, run.Errors)
+	assert.Equal(t, 1, run.Inserted)
+
+	f.failures = 5
+	run = collectOnce(c)
+	assert.Equal(t, model.RunStatusFailed, run.Status)
+	assert.Equal(t, 1, run.Errors)
+}
+
+func TestOffersCollector_CollectCancelled(t *testing.T) {
+	repo := storage.NewMemory()
+	f := &feed{offers: []map[string]interface{}{apiOffer("1", "first")}}
+	c := newTestCollector(f, repo)
+	collectOnce(c)
+
+	// a cancelled load does not stop offers it has not seen
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	c.collect(ctx, c.acs[0], newRunId(), nil, &wg)
+
+	assert.Equal(t, model.RunStatusCancelled, c.lastRuns[1].Status)
+	offer, _ := repo.Offer(1, 1)
+	assert.Equal(t, model.OfferStatusActive, offer.IsActive)
+}
+
+func TestOffersCollector_HoldAnomalies(t *testing.T) {
+	repo := storage.NewMemory()
+	f := &feed{offers: []map[string]interface{}{apiOffer("1", "first"), apiOffer("2", "second"), apiOffer("3", "third")}}
+	c := newTestCollector(f, repo)
+	c.anomaly = anomaly.Config{MaxStoppedRatio: 0.5, Action: model.HoldActionPartial}
+	collectOnce(c)
+
+	// two of three offers disappear, stops are held and the rename is applied
+	f.offers = []map[string]interface{}{apiOffer("1", "first renamed")}
+	run := collectOnce(c)
+	assert.Equal(t, model.RunStatusHeld, run.Status)
+	assert.Equal(t, 1, run.Updated)
+	assert.Equal(t, 0, run.Stopped)
+	assert.Equal(t, 2, run.Held)
+
+	offer, _ := repo.Offer(1, 2)
+	assert.Equal(t, model.OfferStatusActive, offer.IsActive)
+
+	holds := repo.Holds()
+	assert.Len(t, holds, 1)
+	assert.Equal(t, anomaly.CheckStoppedRatio, holds[0].Anomalies[0].Check)
+	assert.Len(t, repo.HeldChanges(holds[0].Id), 2)
+
+	// the next held run supersedes the previous hold
+	collectOnce(c)
+	holds = repo.Holds()
+	assert.Equal(t, model.HoldStatusSuperseded, holds[0].Status)
+	assert.Equal(t, model.HoldStatusHeld, holds[1].Status)
+}
+
+// failingHolds is a repository which cannot store held changes
+type failingHolds struct {
+	*storage.Memory
+}
+
+func (failingHolds) HoldChanges(*model.RunHold, *model.OfferBatch, map[uint64]model.Offer) error {
+	return fmt.Errorf("connection lost")
+}
+
+func TestOffersCollector_HoldFailed(t *testing.T) {
+	repo := failingHolds{storage.NewMemory()}
+	f := &feed{offers: []map[string]interface{}{apiOffer("1", "first"), apiOffer("2", "second"), apiOffer("3", "third")}}
+	c := newTestCollector(f, repo)
+	c.anomaly = anomaly.Config{MaxStoppedRatio: 0.5, Action: model.HoldActionPartial}
+	collectOnce(c)
+
+	// nothing is committed if held changes are not stored, the next run finds them again
+	f.offers = []map[string]interface{}{apiOffer("1", "first renamed")}
+	run := collectOnce(c)
+	assert.Equal(t, model.RunStatusPartial, run.Status)
+	assert.Equal(t, "connection lost", run.Error)
+	assert.Equal(t, 0, run.Updated)
+
+	c.anomaly = anomaly.Config{}
+	run = collectOnce(c)
+	assert.Equal(t, 1, run.Updated)
+	assert.Equal(t, 2, run.Stopped)
+}
+
+// cancellingSink cancels the run on the first write
+type cancellingSink struct {
+	sinks.Sink
+	cancel context.CancelFunc
+}
+
+func (s *cancellingSink) Write(b *model.OfferBatch) error {
+	s.cancel()
+	return s.Sink.Write(b)
+}
+
+func TestOffersCollector_CollectChunks(t *testing.T) {
+	f := &feed{}
+	for i := 1; i <= client.OffersMaxLimit+1; i++ {
+		f.offers = append(f.offers, apiOffer(fmt.Sprint(i), "offer"))
+	}
+	c := newTestCollector(f, storage.NewMemory())
+	ctx, cancel := context.WithCancel(context.Background())
+	c.sink = &cancellingSink{Sink: c.sink, cancel: cancel}
+
+	// without anomaly checks the first chunk is committed while the feed is loaded
+	var wg sync.WaitGroup
+	wg.Add(1)
+	c.collect(ctx, c.acs[0], newRunId(), nil, &wg)
+	run := c.lastRuns[1]
+	assert.Equal(t, model.RunStatusCancelled, run.Status)
+	assert.Equal(t, client.OffersMaxLimit, run.Inserted)
+}
+
+func TestOffersCollector_ResolveHold(t *testing.T) {
+	repo := storage.NewMemory()
+	f := &feed{offers: []map[string]interface{}{apiOffer("1", "first"), apiOffer("2", "second"), apiOffer("3", "third")}}
+	c := newTestCollector(f, repo)
+	c.anomaly = anomaly.Config{MaxStoppedRatio: 0.5, Action: model.HoldActionHold}
+	collectOnce(c)
+
+	f.offers = []map[string]interface{}{apiOffer("1", "first")}
+	collectOnce(c)
+	holdId := repo.Holds()[0].Id
+
+	// one stop is rejected, the other approved and applied
+	hold, err := c.ResolveHold(holdId, []uint64{2}, false, "feed outage")
+	assert.NoError(t, err)
+	assert.Equal(t, model.HoldStatusHeld, hold.Status)
+
+	hold, err = c.ResolveHold(holdId, nil, true, "")
+	assert.NoError(t, err)
+	assert.Equal(t, model.HoldStatusResolved, hold.Status)
+
+	offer, _ := repo.Offer(1, 2)
+	assert.Equal(t, model.OfferStatusActive, offer.IsActive)
+	offer, _ = repo.Offer(1, 3)
+	assert.Equal(t, model.OfferStatusStopped, offer.IsActive)
+
+	changes := repo.HeldChanges(holdId)
+	assert.Equal(t, model.ChangeStatusRejected, changes[0].Status)
+	assert.Equal(t, "feed outage", changes[0].Reason)
+	assert.Equal(t, model.ChangeStatusApproved, changes[1].Status)
+
+	_, err = c.ResolveHold(holdId, nil, true, "")
+	assert.Equal(t, errors.ErrHoldResolved, err)
+	_, err = c.ResolveHold(holdId+1, nil, true, "")
+	assert.Equal(t, errors.ErrHoldNotFound, err)
+}
+
+// recordingSink records offers of written batches
+type recordingSink struct {
+	sinks.Sink
+	offers []uint64
+}
+
+func (s *recordingSink) Write(b *model.OfferBatch) error {
+	for _, list := range [][]model.Offer{b.Inserted, b.Updated, b.Stopped} {
+		for _, offer := range list {
+			s.offers = append(s.offers, offer.Id)
+		}
+	}
+	return s.Sink.Write(b)
+}
+
+func TestOffersCollector_ResolveHoldStored(t *testing.T) {
+	repo := storage.NewMemory()
+	f := &feed{offers: []map[string]interface{}{apiOffer("1", "first"), apiOffer("2", "second"), apiOffer("3", "third")}}
+	c := newTestCollector(f, repo)
+	mirror := &recordingSink{Sink: sinks.NewNDJSON(sinks.TypeNDJSON, ioutil.Discard, nil)}
+	c.sink = sinks.NewFanOut(c.log, c.sink, mirror)
+	c.anomaly = anomaly.Config{MaxStoppedRatio: 0.5, Action: model.HoldActionHold}
+	collectOnce(c)
+
+	f.offers = []map[string]interface{}{apiOffer("1", "first"), apiOffer("4", "fourth")}
+	collectOnce(c)
+	holdId := repo.Holds()[0].Id
+
+	// the insert is stored by a run without anomaly checks with the held hash,
+	// approval writes the remaining stop only and mirrors it
+	c.anomaly = anomaly.Config{}
+	f.offers = []map[string]interface{}{apiOffer("1", "first"), apiOffer("2", "second"), apiOffer("4", "fourth")}
+	collectOnce(c)
+	mirror.offers = nil
+
+	hold, err := c.ResolveHold(holdId, nil, true, "")
+	assert.NoError(t, err)
+	assert.Equal(t, model.HoldStatusApproved, hold.Status)
+	assert.Equal(t, []uint64{2}, mirror.offers)
+
+	offer, _ := repo.Offer(1, 2)
+	assert.Equal(t, model.OfferStatusStopped, offer.IsActive)
+}
+
+func TestOffersCollector_ResolveHoldStop(t *testing.T) {
+	repo := storage.NewMemory()
+	f := &feed{offers: []map[string]interface{}{apiOffer("1", "first"), apiOffer("2", "second"), apiOffer("3", "third")}}
+	c := newTestCollector(f, repo)
+	c.anomaly = anomaly.Config{MaxStoppedRatio: 0.5, Action: model.HoldActionHold}
+	collectOnce(c)
+
+	f.offers = []map[string]interface{}{apiOffer("1", "first")}
+	collectOnce(c)
+	holdId := repo.Holds()[0].Id
+
+	// an approved stop keeps fields stored by a later run
+	c.anomaly = anomaly.Config{}
+	f.offers = []map[string]interface{}{apiOffer("1", "first"), apiOffer("2", "second renamed"), apiOffer("3", "third")}
+	collectOnce(c)
+
+	_, err := c.ResolveHold(holdId, nil, true, "")
+	assert.NoError(t, err)
+
+	offer, _ := repo.Offer(1, 2)
+	assert.Equal(t, model.OfferStatusStopped, offer.IsActive)
+	assert.Equal(t, "second renamed", offer.Title)
+	assert.Equal(t, offer.ContentHash(), offer.Hash)
+}
+
+func TestOffersCollector_SupersedeHolds(t *testing.T) {
+	repo := storage.NewMemory()
+	f := &feed{offers: []map[string]interface{}{apiOffer("1", "first"), apiOffer("2", "second"), apiOffer("3", "third")}}
+	c := newTestCollector(f, repo)
+	c.anomaly = anomaly.Config{MaxStoppedRatio: 0.5, Action: model.HoldActionHold}
+	collectOnce(c)
+
+	f.offers = []map[string]interface{}{apiOffer("1", "first")}
+	collectOnce(c)
+
+	// a failed run is not checked for stop anomalies and keeps the hold
+	f.failures = 5
+	run := collectOnce(c)
+	assert.Equal(t, model.RunStatusFailed, run.Status)
+	assert.Equal(t, model.HoldStatusHeld, repo.Holds()[0].Status)
+
+	// a complete run without anomalies supersedes it
+	f.offers = []map[string]interface{}{apiOffer("1", "first"), apiOffer("2", "second"), apiOffer("3", "third")}
+	run = collectOnce(c)
+	assert.Equal(t, model.RunStatusSuccess, run.Status)
+	assert.Equal(t, model.HoldStatusSuperseded, repo.Holds()[0].Status)
+}
+
+func TestDisabledOffers(t *testing.T) {
+	active := map[uint64]model.Offer{1: {Id: 1, IsActive: true}, 2: {Id: 2, IsActive: true}}
+	b := &model.OfferBatch{
+		Updated: []model.Offer{{Id: 1, IsActive: true}, {Id: 2}, {Id: 3}},
+		Stopped: []model.Offer{{Id: 4}},
+	}
+
+	// offers disabled by an override are stopped unless they are already inactive
+	disabledOffers(b, active)
+	assert.Equal(t, []model.Offer{{Id: 1, IsActive: true}, {Id: 3}}, b.Updated)
+	assert.Equal(t, []model.Offer{{Id: 4}, {Id: 2}}, b.Stopped)
+}
+
+func TestOffersCollector_DisabledAnomaly(t *testing.T) {
+	repo := storage.NewMemory()
+	f := &feed{offers: []map[string]interface{}{apiOffer("1", "first"), apiOffer("2", "second"), apiOffer("3", "third")}}
+	c := newTestCollector(f, repo)
+	c.anomaly = anomaly.Config{MaxStoppedRatio: 0.5, Action: model.HoldActionHold}
+	collectOnce(c)
+
+	// offers disabled by overrides are not upstream stops
+	c.overrides.Replace([]model.OfferOverride{
+		{AccountId: 1, OfferId: 2, Disabled: true},
+		{AccountId: 1, OfferId: 3, Disabled: true},
+	})
+	run := collectOnce(c)
+	assert.Equal(t, model.RunStatusSuccess, run.Status)
+	assert.Equal(t, 2, run.Stopped)
+	assert.Empty(t, repo.Holds())
+}
+
+func TestOffersCollector_Overrides(t *testing.T) {
+	repo := storage.NewMemory()
+	f := &feed{offers: []map[string]interface{}{apiOffer("1", "first"), apiOffer("2", "second")}}
+	c := newTestCollector(f, repo)
+	limit, above := 1.0, 2.0
+	c.overrides.Replace([]model.OfferOverride{
+		{AccountId: 1, OfferId: 1, PayoutCap: &limit},
+		{AccountId: 1, OfferId: 2, PayoutCap: &above},
+	})
+	collectOnce(c)
+
+	// the override is kept by later syncs
+	run := collectOnce(c)
+	assert.Equal(t, 0, run.Updated)
+	offer, _ := repo.Offer(1, 1)
+	assert.Equal(t, 1.0, offer.Payout)
+	assert.Equal(t, 1.5, offer.Upstream.Payout)
+
+	// an override changing nothing keeps no upstream
+	offer, _ = repo.Offer(1, 2)
+	assert.Nil(t, offer.Upstream)
+
+	// removing the override restores upstream values
+	c.overrides.Replace(nil)
+	run = collectOnce(c)
+	assert.Equal(t, 1, run.Updated)
+	offer, _ = repo.Offer(1, 1)
+	assert.Equal(t, 1.5, offer.Payout)
+	assert.Nil(t, offer.Upstream)
+	assert.Nil(t, offer.Overridden)
+
+	// an offer disabled with a payout change is stopped with its new payout recorded
+	c.overrides.Replace([]model.OfferOverride{{AccountId: 1, OfferId: 2, Disabled: true}})
+	f.offers[1]["attributes"].(map[string]interface{})["rate"] = "2"
+	run = collectOnce(c)
+	assert.Equal(t, 1, run.Stopped)
+	offer, _ = repo.Offer(1, 2)
+	assert.Equal(t, model.OfferStatusStopped, offer.IsActive)
+	assert.Equal(t, 2.0, offer.Payout)
+	payouts := repo.Payouts()
+	assert.Equal(t, 2.0, payouts[len(payouts)-1].Payout)
+}
+
+func TestOffersCollector_IngestRules(t *testing.T) {
+	repo := storage.NewMemory()
+	f := &feed{offers: []map[string]interface{}{apiOffer("1", "first"), apiOffer("2", "second")}}
+	c := newTestCollector(f, repo)
+	collectOnce(c)
+
+	// offers below the minimum payout are skipped and stored ones are stopped
+	cheap := apiOffer("2", "second")
+	cheap["attributes"].(map[string]interface{})["rate"] = "0.1"
+	f.offers = []map[string]interface{}{apiOffer("1", "first"), cheap, apiOffer("3", "third")}
+	c.ingest = ingest.Rules{{AccountId: 1, MinPayout: 0.5}}
+	run := collectOnce(c)
+	assert.Equal(t, 3, run.Seen)
+	assert.Equal(t, 1, run.Skipped)
+	assert.Equal(t, 1, run.Inserted)
+	assert.Equal(t, 1, run.Stopped)
+
+	offer, _ := repo.Offer(1, 2)
+	assert.Equal(t, model.OfferStatusStopped, offer.IsActive)
+}
+
+func TestOffersCollector_IngestRulesAnomaly(t *testing.T) {
+	repo := storage.NewMemory()
+	f := &feed{offers: []map[string]interface{}{apiOffer("1", "first"), apiOffer("2", "second"), apiOffer("3", "third")}}
+	c := newTestCollector(f, repo)
+	c.anomaly = anomaly.Config{MaxCountDelta: 0.5, MaxStoppedRatio: 0.5, Action: model.HoldActionPartial}
+	collectOnce(c)
+
+	// stops of offers skipped by a new rule are not anomalies
+	c.ingest = ingest.Rules{{AccountId: 1, MinPayout: 2}}
+	run := collectOnce(c)
+	assert.Equal(t, model.RunStatusSuccess, run.Status)
+	assert.Equal(t, 3, run.Skipped)
+	assert.Equal(t, 3, run.Stopped)
+	assert.Empty(t, repo.Holds())
+}
+
+func TestOffersCollector_StartJob(t *testing.T) {
+	c := newTestCollector(&feed{}, storage.NewMemory())
+
+	// accounts are acquired when the job starts, a second start fails before the first one runs
+	_, err := c.StartJob(&collectors.Job{Accounts: []int{1}})
+	assert.NoError(t, err)
+	assert.True(t, c.IsRunning([]int{1}))
+
+	_, err = c.StartJob(&collectors.Job{})
+	assert.Equal(t, errors.ErrCollectorIsRunning, err)
+	_, err = c.StartJob(&collectors.Job{Accounts: []int{2}})
+	assert.Equal(t, errors.ErrAccountNotFound, err)
+}
+
+func TestOffersCollector_AcquireDuplicates(t *testing.T) {
+	c := newTestCollector(&feed{}, storage.NewMemory())
+
+	// an account listed twice is acquired once
+	accounts, err := c.acquire([]*model.Account{c.acs[0], c.acs[0]}, true)
+	assert.NoError(t, err)
+	assert.Len(t, accounts, 1)
+
+	// a failed acquire releases accounts it marked
+	c.release(accounts)
+	second := &model.Account{Id: 2}
+	c.running[1] = true
+	_, err = c.acquire([]*model.Account{second, c.acs[0]}, false)
+	assert.Equal(t, errors.ErrCollectorIsRunning, err)
+	assert.False(t, c.IsRunning([]int{2}))
+}
+
+func TestOffersCollector_SetTimeInterval(t *testing.T) {
+	c := newTestCollector(&feed{}, storage.NewMemory())
+	c.SetTimeInterval("every minute")
+
+	// the interval is changed while the scheduler reads it
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		c.SetTimeInterval("every 5 minutes")
+	}()
+	go func() {
+		defer wg.Done()
+		c.TimeInterval()
+	}()
+	wg.Wait()
+
+	assert.Equal(t, "every 5 minutes", c.TimeInterval())
+}
+
+func TestOffersCollector_PublishOrder(t *testing.T) {
+	repo := storage.NewMemory()
+	c := newTestCollector(&feed{}, repo)
+	sub := c.hub.Subscribe(events.Filter{})
+
+	// batches written at the same time are published in seq order
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(id uint64) {
+			defer wg.Done()
+			c.commit(&model.OfferBatch{Inserted: []model.Offer{{Id: id, AccountId: 1}}}, &accountRun{})
+		}(uint64(i))
+	}
+	wg.Wait()
+
+	last := int64(0)
+	for i := 0; i < 20; i++ {
+		e := <-sub.C
+		assert.True(t, e.Seq > last)
+		last = e.Seq
+	}
+}
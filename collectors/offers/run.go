@@ -0,0 +1,139 @@
+package offers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"mobilda/alerts"
+	"mobilda/client/response"
+	"mobilda/collectors"
+	"mobilda/errors"
+	"mobilda/model"
+)
+
+// accountRun tracks the counters of a single account during a collector run.
+// Counters are updated both from the collector and from the api reader goroutine.
+type accountRun struct {
+	sync.Mutex
+	run        model.CollectorRun
+	curPage    uint32
+	totalPages uint32
+	aborted    bool
+	cancelled  bool
+	held       bool
+	alerts     []alerts.Alert
+	skipped    map[string]int
+}
+
+func newRunId() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (r *accountRun) add(counter *int, n int) {
+	r.Lock()
+	*counter += n
+	r.Unlock()
+}
+
+func (r *accountRun) fail(err error) {
+	r.Lock()
+	defer r.Unlock()
+	r.run.Errors++
+	r.run.Error = err.Error()
+	if err == errors.ErrApiUnavailable {
+		r.aborted = true
+	}
+}
+
+// page is called by the api reader after every loaded page
+func (r *accountRun) page(summary response.Pagination) {
+	r.Lock()
+	defer r.Unlock()
+	r.run.Pages++
+	r.curPage = summary.CurrentPage
+	r.totalPages = summary.TotalPages
+}
+
+// hold counts changes held because of anomalies
+func (r *accountRun) hold(n int) {
+	r.Lock()
+	r.held = true
+	r.run.Held += n
+	r.Unlock()
+}
+
+// skip counts an offer skipped by ingest rules by reason
+func (r *accountRun) skip(reason string) {
+	r.Lock()
+	defer r.Unlock()
+	r.run.Skipped++
+	if r.skipped == nil {
+		r.skipped = map[string]int{}
+	}
+	r.skipped[reason]++
+}
+
+// skipReasons returns counts of skipped offers by reason
+func (r *accountRun) skipReasons() map[string]int {
+	r.Lock()
+	defer r.Unlock()
+	reasons := make(map[string]int, len(r.skipped))
+	for reason, n := range r.skipped {
+		reasons[reason] = n
+	}
+	return reasons
+}
+
+// alert adds alerts sent when the run is finished
+func (r *accountRun) alert(list ...alerts.Alert) {
+	r.Lock()
+	r.alerts = append(r.alerts, list...)
+	r.Unlock()
+}
+
+func (r *accountRun) cancel() {
+	r.Lock()
+	r.cancelled = true
+	r.Unlock()
+}
+
+// complete reports whether all offers of the account were loaded
+func (r *accountRun) complete() bool {
+	r.Lock()
+	defer r.Unlock()
+	return !r.aborted && !r.cancelled
+}
+
+func (r *accountRun) finish() model.CollectorRun {
+	r.Lock()
+	defer r.Unlock()
+	r.run.FinishedAt = time.Now()
+	switch {
+	case r.cancelled:
+		r.run.Status = model.RunStatusCancelled
+	case r.aborted:
+		r.run.Status = model.RunStatusFailed
+	case r.held:
+		r.run.Status = model.RunStatusHeld
+	case r.run.Errors > 0:
+		r.run.Status = model.RunStatusPartial
+	default:
+		r.run.Status = model.RunStatusSuccess
+	}
+	return r.run
+}
+
+// Progress implements collectors.ProgressReporter
+func (r *accountRun) Progress() collectors.Progress {
+	r.Lock()
+	defer r.Unlock()
+	return collectors.Progress{
+		CollectorRun: r.run,
+		Page:         r.curPage,
+		TotalPages:   r.totalPages,
+	}
+}
@@ -0,0 +1,207 @@
+package collectors
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"mobilda/errors"
+	"mobilda/model"
+
+	"bitbucket.org/mobio/go-collector"
+	"bitbucket.org/mobio/go-dbmanager"
+	"bitbucket.org/mobio/go-logger"
+	"bitbucket.org/mobio/go-scheduler"
+)
+
+// Status describes the state of a registered collector
+type Status struct {
+	Name            string               `json:"name"`
+	Interval        string               `json:"interval"`
+	IsPaused        bool                 `json:"is_paused"`
+	IsRunning       bool                 `json:"is_running"`
+	RunningAccounts []int                `json:"running_accounts,omitempty"`
+	LastRunAt       time.Time            `json:"last_run_at"`
+	LastRunDuration string               `json:"last_run_duration"`
+	NextRunAt       time.Time            `json:"next_run_at"`
+	Accounts        []model.CollectorRun `json:"accounts,omitempty"`
+}
+
+// StatusReporter is implemented by collectors able to report their state
+type StatusReporter interface {
+	Status() Status
+}
+
+// Registry keeps collectors added to the scheduler, so they could be looked up by name,
+// and jobs started manually through the admin api
+type Registry struct {
+	lock       sync.RWMutex
+	scheduler  *scheduler.Scheduler
+	db         *dbmanager.DbManager
+	log        *logger.Logger
+	collectors map[string]collector.ICollector
+	schedules  map[string]model.CollectorSchedule
+	jobs       map[string]*Job
+
+	// scheduleLock serializes schedule updates, each one reads and writes the whole schedule
+	scheduleLock sync.Mutex
+}
+
+func NewRegistry(s *scheduler.Scheduler, db *dbmanager.DbManager, l *logger.Logger) *Registry {
+	return &Registry{
+		scheduler:  s,
+		db:         db,
+		log:        l,
+		collectors: map[string]collector.ICollector{},
+		schedules:  map[string]model.CollectorSchedule{},
+		jobs:       map[string]*Job{},
+	}
+}
+
+// Add adds collector to the scheduler and registers it by name.
+// Schedule changed at runtime is restored before the collector is scheduled.
+func (r *Registry) Add(name string, c collector.ICollector) {
+	r.lock.Lock()
+	r.collectors[name] = c
+	r.lock.Unlock()
+
+	r.restoreSchedule(name, c)
+
+	r.scheduler.AddTimeIntervalCollector(name, c)
+}
+
+func (r *Registry) Get(name string) (collector.ICollector, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	c, ok := r.collectors[name]
+	return c, ok
+}
+
+// Names returns sorted names of registered collectors
+func (r *Registry) Names() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Status returns state of collector by name
+func (r *Registry) Status(name string) (Status, bool) {
+	c, ok := r.Get(name)
+	if !ok {
+		return Status{}, false
+	}
+
+	status := Status{Interval: c.TimeInterval()}
+	if reporter, ok := c.(StatusReporter); ok {
+		status = reporter.Status()
+	}
+	status.Name = name
+	status.IsPaused = r.schedule(name).IsPaused
+
+	return status, true
+}
+
+// Statuses returns states of all registered collectors
+func (r *Registry) Statuses() []Status {
+	statuses := []Status{}
+	for _, name := range r.Names() {
+		if status, ok := r.Status(name); ok {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// Run starts collector as a job in background.
+// Accounts limit the run to a subset of accounts, empty accounts mean all of them.
+func (r *Registry) Run(name string, accounts []int) (*Job, error) {
+	c, ok := r.Get(name)
+	if !ok {
+		return nil, errors.ErrCollectorNotFound
+	}
+
+	runner, isRunner := c.(JobRunner)
+	if !isRunner && len(accounts) > 0 {
+		return nil, errors.ErrAccountsNotSupported
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.pruneJobs()
+	for _, job := range r.jobs {
+		if job.Status() == JobStatusRunning && job.overlaps(name, accounts) {
+			return nil, errors.ErrCollectorIsRunning
+		}
+	}
+
+	job := newJob(name, accounts)
+	if !isRunner {
+		r.jobs[job.Id] = job
+		go func() {
+			c.Run()
+			job.finish(nil)
+		}()
+		return job, nil
+	}
+
+	run, err := runner.StartJob(job)
+	if err != nil {
+		job.Cancel()
+		return nil, err
+	}
+	r.jobs[job.Id] = job
+	go func() {
+		job.finish(run())
+	}()
+
+	return job, nil
+}
+
+func (r *Registry) Job(id string) (*Job, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	job, ok := r.jobs[id]
+	return job, ok
+}
+
+// CancelJob cancels running job, the collector stops it cooperatively
+func (r *Registry) CancelJob(id string) (*Job, error) {
+	job, ok := r.Job(id)
+	if !ok {
+		return nil, errors.ErrJobNotFound
+	}
+	job.Cancel()
+	return job, nil
+}
+
+// pruneJobs removes jobs finished long ago, must be called under lock
+func (r *Registry) pruneJobs() {
+	for id, job := range r.jobs {
+		job.lock.RLock()
+		expired := !job.finishedAt.IsZero() && time.Since(job.finishedAt) > jobRetention
+		job.lock.RUnlock()
+		if expired {
+			delete(r.jobs, id)
+		}
+	}
+}
+
+// NextRun returns time of the next scheduled run, or zero time if it cannot be calculated
+func NextRun(lastRun time.Time, interval string) time.Time {
+	d, err := ParseInterval(interval)
+	if err != nil || lastRun.IsZero() {
+		return time.Time{}
+	}
+	return lastRun.Add(d)
+}
+
+func FromContext(ctx context.Context, key string) *Registry {
+	return ctx.Value(key).(*Registry)
+}
@@ -0,0 +1,131 @@
+package collectors
+
+import (
+	"time"
+
+	"mobilda/errors"
+	"mobilda/model"
+
+	"bitbucket.org/mobio/go-collector"
+	"gopkg.in/pg.v5"
+)
+
+// Schedulable is implemented by collectors which could be paused and rescheduled at runtime.
+// Paused collectors skip scheduled runs, manual jobs still run.
+type Schedulable interface {
+	SetTimeInterval(interval string)
+	SetPaused(paused bool)
+}
+
+// Reschedule validates and persists new time interval of the collector
+func (r *Registry) Reschedule(name, interval string) (Status, error) {
+	if _, err := ParseInterval(interval); err != nil {
+		return Status{}, err
+	}
+
+	return r.updateSchedule(name, func(s *model.CollectorSchedule) {
+		s.TimeInterval = interval
+	})
+}
+
+// ResetInterval restores config time interval of the collector
+func (r *Registry) ResetInterval(name string) (Status, error) {
+	return r.updateSchedule(name, func(s *model.CollectorSchedule) {
+		s.TimeInterval = ""
+	})
+}
+
+// Pause persists paused state of the collector, scheduled runs are skipped until resumed
+func (r *Registry) Pause(name string) (Status, error) {
+	return r.updateSchedule(name, func(s *model.CollectorSchedule) {
+		s.IsPaused = true
+	})
+}
+
+func (r *Registry) Resume(name string) (Status, error) {
+	return r.updateSchedule(name, func(s *model.CollectorSchedule) {
+		s.IsPaused = false
+	})
+}
+
+func (r *Registry) schedule(name string) model.CollectorSchedule {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if s, ok := r.schedules[name]; ok {
+		return s
+	}
+	return model.CollectorSchedule{Collector: name}
+}
+
+func (r *Registry) updateSchedule(name string, update func(s *model.CollectorSchedule)) (Status, error) {
+	c, ok := r.Get(name)
+	if !ok {
+		return Status{}, errors.ErrCollectorNotFound
+	}
+	sc, ok := c.(Schedulable)
+	if !ok {
+		return Status{}, errors.ErrCollectorNotSchedulable
+	}
+
+	r.scheduleLock.Lock()
+	defer r.scheduleLock.Unlock()
+
+	s := r.schedule(name)
+	update(&s)
+	s.UpdatedAt = time.Now()
+
+	_, err := r.db.Model(&s).
+		OnConflict("(collector) DO UPDATE").
+		Set("time_interval = EXCLUDED.time_interval").
+		Set("is_paused = EXCLUDED.is_paused").
+		Set("updated_at = EXCLUDED.updated_at").
+		Insert()
+	if err != nil {
+		return Status{}, err
+	}
+
+	r.lock.Lock()
+	r.schedules[name] = s
+	r.lock.Unlock()
+
+	applySchedule(sc, s)
+	r.log.WithField("collector", name).
+		Infof("Collector schedule changed: interval %q, paused %t", c.TimeInterval(), s.IsPaused)
+
+	status, _ := r.Status(name)
+	return status, nil
+}
+
+// restoreSchedule applies persisted schedule to the collector
+func (r *Registry) restoreSchedule(name string, c collector.ICollector) {
+	sc, ok := c.(Schedulable)
+	if !ok {
+		return
+	}
+
+	s := model.CollectorSchedule{Collector: name}
+	if err := r.db.Model(&s).Where("collector = ?", name).Select(); err != nil {
+		if err != pg.ErrNoRows {
+			r.log.WithField("collector", name).Error(err)
+		}
+		return
+	}
+
+	if s.TimeInterval != "" {
+		if _, err := ParseInterval(s.TimeInterval); err != nil {
+			r.log.WithField("collector", name).Error(err)
+			s.TimeInterval = ""
+		}
+	}
+
+	r.lock.Lock()
+	r.schedules[name] = s
+	r.lock.Unlock()
+
+	applySchedule(sc, s)
+}
+
+func applySchedule(sc Schedulable, s model.CollectorSchedule) {
+	sc.SetTimeInterval(s.TimeInterval)
+	sc.SetPaused(s.IsPaused)
+}
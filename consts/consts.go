@@ -14,7 +14,31 @@ const (
 
 	Scheduler_Component_Key = "scheduler.component"
 
+	Collectors_Component_Key = "collectors.component"
+
 	DbManager_Component_Key = "dbmanager.component"
 
 	Cache_Component_Key = "cache.component"
+
+	Repository_Component_Key = "repository.component"
+
+	Events_Component_Key = "events.component"
+
+	Matcher_Component_Key = "matcher.component"
+
+	Taxonomy_Component_Key = "taxonomy.component"
+
+	Overrides_Component_Key = "overrides.component"
+
+	Alerts_Component_Key = "alerts.component"
+	Alerts_Key           = "alerts"
+
+	Anomaly_Component_Key = "anomaly.component"
+	Anomaly_Key           = "anomaly"
+
+	Ingest_Component_Key = "ingest.component"
+	Ingest_Key           = "ingest"
+
+	Sink_Component_Key = "sink.component"
+	Sinks_Key          = "sinks"
 )
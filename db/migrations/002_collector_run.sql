@@ -0,0 +1,34 @@
+-- +goose Up
+
+CREATE TABLE mobilda.collector_run (
+  id                     BIGSERIAL PRIMARY KEY,
+  run_id                 TEXT                                              NOT NULL CHECK (length(run_id) <= 64),
+  collector              TEXT                                              NOT NULL CHECK (length(collector) <= 255),
+  account_id             INT                                               NOT NULL,
+  status                 TEXT                                              NOT NULL CHECK (length(status) <= 32),
+  started_at             TIMESTAMP WITH TIME ZONE                          NOT NULL,
+  finished_at            TIMESTAMP WITH TIME ZONE,
+  pages                  INT DEFAULT 0                                     NOT NULL,
+  seen                   INT DEFAULT 0                                     NOT NULL,
+  inserted               INT DEFAULT 0                                     NOT NULL,
+  updated                INT DEFAULT 0                                     NOT NULL,
+  stopped                INT DEFAULT 0                                     NOT NULL,
+  rejected               INT DEFAULT 0                                     NOT NULL,
+  errors                 INT DEFAULT 0                                     NOT NULL,
+  error                  TEXT                                              CHECK (length(error) <= 15000),
+  created_at             TIMESTAMP WITH TIME ZONE DEFAULT now()            NOT NULL
+);
+
+ALTER TABLE mobilda.collector_run
+  ADD CONSTRAINT collector_run_account_fk
+FOREIGN KEY (account_id)
+REFERENCES mobilda.account
+ON DELETE CASCADE;
+
+CREATE INDEX collector_run_started_idx ON mobilda.collector_run (collector, started_at DESC);
+CREATE INDEX collector_run_account_idx ON mobilda.collector_run (account_id, started_at DESC);
+CREATE INDEX collector_run_run_idx ON mobilda.collector_run (run_id);
+
+
+-- +goose Down
+DROP TABLE mobilda.collector_run;
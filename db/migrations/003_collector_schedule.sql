@@ -0,0 +1,12 @@
+-- +goose Up
+
+CREATE TABLE mobilda.collector_schedule (
+  collector              TEXT PRIMARY KEY                                  CHECK (length(collector) <= 255),
+  time_interval          TEXT                                              CHECK (length(time_interval) <= 255),
+  is_paused              BOOLEAN DEFAULT FALSE                             NOT NULL,
+  updated_at             TIMESTAMP WITH TIME ZONE DEFAULT now()            NOT NULL
+);
+
+
+-- +goose Down
+DROP TABLE mobilda.collector_schedule;
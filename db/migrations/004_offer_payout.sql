@@ -0,0 +1,15 @@
+-- +goose Up
+
+ALTER TABLE mobilda.offer ADD COLUMN payout NUMERIC;
+
+UPDATE mobilda.offer
+SET payout = rate :: NUMERIC
+WHERE rate ~ '^[+-]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][+-]?[0-9]+)?$';
+
+CREATE INDEX offer_payout_idx ON mobilda.offer (payout);
+CREATE INDEX offer_account_active_idx ON mobilda.offer (account_id, is_active);
+
+
+-- +goose Down
+DROP INDEX mobilda.offer_account_active_idx;
+ALTER TABLE mobilda.offer DROP COLUMN payout;
@@ -0,0 +1,21 @@
+-- +goose Up
+
+CREATE TABLE mobilda.offer_change (
+  seq                    BIGSERIAL PRIMARY KEY,
+  account_id             INT                                               NOT NULL,
+  offer_id               BIGINT                                            NOT NULL,
+  change_type            TEXT                                              NOT NULL CHECK (length(change_type) <= 32),
+  changed_at             TIMESTAMP WITH TIME ZONE DEFAULT now()            NOT NULL
+);
+
+ALTER TABLE mobilda.offer_change
+  ADD CONSTRAINT offer_change_account_fk
+FOREIGN KEY (account_id)
+REFERENCES mobilda.account
+ON DELETE CASCADE;
+
+CREATE INDEX offer_change_offer_idx ON mobilda.offer_change (account_id, offer_id);
+
+
+-- +goose Down
+DROP TABLE mobilda.offer_change;
@@ -0,0 +1,40 @@
+-- +goose Up
+
+CREATE TABLE mobilda.webhook (
+  id                     SERIAL PRIMARY KEY,
+  url                    TEXT                                              NOT NULL CHECK (length(url) <= 2048),
+  secret                 TEXT                                              NOT NULL CHECK (length(secret) <= 255),
+  accounts               INT[],
+  countries              TEXT[],
+  types                  TEXT[],
+  is_active              BOOLEAN DEFAULT TRUE                              NOT NULL,
+  created_at             TIMESTAMP WITH TIME ZONE DEFAULT now()            NOT NULL
+);
+
+CREATE TABLE mobilda.webhook_delivery (
+  id                     BIGSERIAL PRIMARY KEY,
+  webhook_id             INT                                               NOT NULL,
+  seq                    BIGINT                                            NOT NULL,
+  event_type             TEXT                                              NOT NULL CHECK (length(event_type) <= 32),
+  payload                JSONB                                             NOT NULL,
+  status                 TEXT DEFAULT 'pending'                            NOT NULL CHECK (length(status) <= 32),
+  attempts               INT DEFAULT 0                                     NOT NULL,
+  next_attempt_at        TIMESTAMP WITH TIME ZONE DEFAULT now()            NOT NULL,
+  last_error             TEXT,
+  created_at             TIMESTAMP WITH TIME ZONE DEFAULT now()            NOT NULL,
+  delivered_at           TIMESTAMP WITH TIME ZONE
+);
+
+ALTER TABLE mobilda.webhook_delivery
+  ADD CONSTRAINT webhook_delivery_webhook_fk
+FOREIGN KEY (webhook_id)
+REFERENCES mobilda.webhook
+ON DELETE CASCADE;
+
+CREATE INDEX webhook_delivery_pending_idx ON mobilda.webhook_delivery (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_delivery_webhook_idx ON mobilda.webhook_delivery (webhook_id, status);
+
+
+-- +goose Down
+DROP TABLE mobilda.webhook_delivery;
+DROP TABLE mobilda.webhook;
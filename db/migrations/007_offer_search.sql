@@ -0,0 +1,18 @@
+-- +goose Up
+
+ALTER TABLE mobilda.offer ADD COLUMN search_vector TSVECTOR;
+
+-- the expression is kept in sync with model.OfferSearchVector, the collector updates the column
+UPDATE mobilda.offer
+SET search_vector =
+  setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+  setweight(to_tsvector('simple', replace(coalesce(package_name, ''), '.', ' ')), 'A') ||
+  setweight(to_tsvector('simple', coalesce(developer, '')), 'B') ||
+  setweight(to_tsvector('simple', coalesce(description, '')), 'C');
+
+CREATE INDEX offer_search_idx ON mobilda.offer USING GIN (search_vector);
+
+
+-- +goose Down
+DROP INDEX mobilda.offer_search_idx;
+ALTER TABLE mobilda.offer DROP COLUMN search_vector;
@@ -0,0 +1,24 @@
+-- +goose Up
+
+-- normalized allowed_devices and min_os_version, filled by the collector: normalized fields are part
+-- of the offer hash, so every offer is rewritten on the first sync
+ALTER TABLE mobilda.offer ADD COLUMN platforms TEXT[];
+ALTER TABLE mobilda.offer ADD COLUMN device_classes TEXT[];
+ALTER TABLE mobilda.offer ADD COLUMN min_android_version INT[];
+ALTER TABLE mobilda.offer ADD COLUMN min_ios_version INT[];
+ALTER TABLE mobilda.offer ADD COLUMN unparsed_targeting TEXT[];
+
+CREATE INDEX offer_platforms_idx ON mobilda.offer USING GIN (platforms);
+CREATE INDEX offer_min_android_version_idx ON mobilda.offer (min_android_version);
+CREATE INDEX offer_min_ios_version_idx ON mobilda.offer (min_ios_version);
+
+
+-- +goose Down
+DROP INDEX mobilda.offer_min_ios_version_idx;
+DROP INDEX mobilda.offer_min_android_version_idx;
+DROP INDEX mobilda.offer_platforms_idx;
+ALTER TABLE mobilda.offer DROP COLUMN unparsed_targeting;
+ALTER TABLE mobilda.offer DROP COLUMN min_ios_version;
+ALTER TABLE mobilda.offer DROP COLUMN min_android_version;
+ALTER TABLE mobilda.offer DROP COLUMN device_classes;
+ALTER TABLE mobilda.offer DROP COLUMN platforms;
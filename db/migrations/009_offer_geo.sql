@@ -0,0 +1,9 @@
+-- +goose Up
+
+-- countries and languages are rewritten to ISO codes by the collector on the next sync,
+-- values it does not know are kept and listed here
+ALTER TABLE mobilda.offer ADD COLUMN unknown_geo TEXT[];
+
+
+-- +goose Down
+ALTER TABLE mobilda.offer DROP COLUMN unknown_geo;
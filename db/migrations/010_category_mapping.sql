@@ -0,0 +1,20 @@
+-- +goose Up
+
+CREATE TABLE mobilda.category_mapping (
+  category               TEXT PRIMARY KEY                                  CHECK (length(category) <= 255),
+  vertical               TEXT                                              NOT NULL CHECK (length(vertical) <= 255),
+  updated_at             TIMESTAMP WITH TIME ZONE DEFAULT now()            NOT NULL
+);
+
+-- filled by the collector, verticals are part of the offer hash
+ALTER TABLE mobilda.offer ADD COLUMN verticals TEXT[];
+ALTER TABLE mobilda.offer ADD COLUMN unmapped_categories TEXT[];
+
+CREATE INDEX offer_verticals_idx ON mobilda.offer USING GIN (verticals);
+
+
+-- +goose Down
+DROP INDEX mobilda.offer_verticals_idx;
+ALTER TABLE mobilda.offer DROP COLUMN unmapped_categories;
+ALTER TABLE mobilda.offer DROP COLUMN verticals;
+DROP TABLE mobilda.category_mapping;
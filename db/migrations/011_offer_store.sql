@@ -0,0 +1,22 @@
+-- +goose Up
+
+-- store app of the preview url, filled by the collector on the next sync
+ALTER TABLE mobilda.offer ADD COLUMN store TEXT;
+ALTER TABLE mobilda.offer ADD COLUMN store_platform TEXT;
+ALTER TABLE mobilda.offer ADD COLUMN store_app_id TEXT;
+ALTER TABLE mobilda.offer ADD COLUMN bundle_id TEXT;
+ALTER TABLE mobilda.offer ADD COLUMN package_mismatch BOOLEAN DEFAULT FALSE NOT NULL;
+
+-- offers are joined with the app catalogue by store id
+CREATE INDEX offer_store_app_idx ON mobilda.offer (store, store_app_id);
+CREATE INDEX offer_bundle_id_idx ON mobilda.offer (bundle_id);
+
+
+-- +goose Down
+DROP INDEX mobilda.offer_bundle_id_idx;
+DROP INDEX mobilda.offer_store_app_idx;
+ALTER TABLE mobilda.offer DROP COLUMN package_mismatch;
+ALTER TABLE mobilda.offer DROP COLUMN bundle_id;
+ALTER TABLE mobilda.offer DROP COLUMN store_app_id;
+ALTER TABLE mobilda.offer DROP COLUMN store_platform;
+ALTER TABLE mobilda.offer DROP COLUMN store;
@@ -0,0 +1,24 @@
+-- +goose Up
+
+CREATE TABLE mobilda.app (
+  id                     BIGSERIAL PRIMARY KEY,
+  store                  TEXT                                              NOT NULL,
+  store_app_id           TEXT                                              NOT NULL,
+  platform               TEXT,
+  bundle_id              TEXT,
+  title                  TEXT,
+  created_at             TIMESTAMP WITH TIME ZONE DEFAULT now()            NOT NULL,
+  updated_at             TIMESTAMP WITH TIME ZONE DEFAULT now()            NOT NULL,
+  UNIQUE (store, store_app_id)
+);
+
+-- offers are linked when they are written by the collector
+ALTER TABLE mobilda.offer ADD COLUMN app_id BIGINT REFERENCES mobilda.app (id) ON DELETE SET NULL;
+
+CREATE INDEX offer_app_id_idx ON mobilda.offer (app_id);
+
+
+-- +goose Down
+DROP INDEX mobilda.offer_app_id_idx;
+ALTER TABLE mobilda.offer DROP COLUMN app_id;
+DROP TABLE mobilda.app;
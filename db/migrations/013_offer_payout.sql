@@ -0,0 +1,29 @@
+-- +goose Up
+
+CREATE TABLE mobilda.offer_payout (
+  id                     BIGSERIAL PRIMARY KEY,
+  account_id             INT                                               NOT NULL,
+  offer_id               BIGINT                                            NOT NULL,
+  payout                 NUMERIC                                           NOT NULL,
+  rate                   TEXT,
+  currency               TEXT,
+  business_model         TEXT,
+  recorded_at            TIMESTAMP WITH TIME ZONE DEFAULT now()            NOT NULL
+);
+
+ALTER TABLE mobilda.offer_payout
+  ADD CONSTRAINT offer_payout_account_fk
+FOREIGN KEY (account_id)
+REFERENCES mobilda.account
+ON DELETE CASCADE;
+
+CREATE INDEX offer_payout_offer_idx ON mobilda.offer_payout (account_id, offer_id, recorded_at);
+
+-- the series starts with the current state of offers
+INSERT INTO mobilda.offer_payout (account_id, offer_id, payout, rate, currency, business_model)
+SELECT account_id, offer_id, COALESCE(payout, 0), rate, currency, business_model
+FROM mobilda.offer;
+
+
+-- +goose Down
+DROP TABLE mobilda.offer_payout;
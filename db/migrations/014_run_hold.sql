@@ -0,0 +1,41 @@
+-- +goose Up
+
+CREATE TABLE mobilda.run_hold (
+  id                     BIGSERIAL PRIMARY KEY,
+  run_id                 TEXT                                              NOT NULL CHECK (length(run_id) <= 64),
+  account_id             INT                                               NOT NULL,
+  action                 TEXT                                              NOT NULL CHECK (length(action) <= 32),
+  status                 TEXT                                              NOT NULL CHECK (length(status) <= 32),
+  anomalies              JSONB,
+  changes                INT DEFAULT 0                                     NOT NULL,
+  created_at             TIMESTAMP WITH TIME ZONE DEFAULT now()            NOT NULL,
+  resolved_at            TIMESTAMP WITH TIME ZONE
+);
+
+ALTER TABLE mobilda.run_hold
+  ADD CONSTRAINT run_hold_account_fk
+FOREIGN KEY (account_id)
+REFERENCES mobilda.account
+ON DELETE CASCADE;
+
+CREATE INDEX run_hold_account_idx ON mobilda.run_hold (account_id, status);
+
+-- offer is the state the change would write
+CREATE TABLE mobilda.held_change (
+  id                     BIGSERIAL PRIMARY KEY,
+  hold_id                BIGINT                                            NOT NULL REFERENCES mobilda.run_hold (id) ON DELETE CASCADE,
+  account_id             INT                                               NOT NULL,
+  offer_id               BIGINT                                            NOT NULL,
+  change_type            TEXT                                              NOT NULL CHECK (length(change_type) <= 32),
+  offer                  JSONB                                             NOT NULL
+);
+
+CREATE INDEX held_change_hold_idx ON mobilda.held_change (hold_id);
+
+ALTER TABLE mobilda.collector_run ADD COLUMN held INT DEFAULT 0 NOT NULL;
+
+
+-- +goose Down
+ALTER TABLE mobilda.collector_run DROP COLUMN held;
+DROP TABLE mobilda.held_change;
+DROP TABLE mobilda.run_hold;
@@ -0,0 +1,14 @@
+-- +goose Up
+
+-- previous is the offer stored when the run was held, changes are approved or rejected by an operator
+ALTER TABLE mobilda.held_change ADD COLUMN status TEXT DEFAULT 'pending' NOT NULL CHECK (length(status) <= 32);
+ALTER TABLE mobilda.held_change ADD COLUMN previous JSONB;
+ALTER TABLE mobilda.held_change ADD COLUMN reason TEXT CHECK (length(reason) <= 15000);
+ALTER TABLE mobilda.held_change ADD COLUMN resolved_at TIMESTAMP WITH TIME ZONE;
+
+
+-- +goose Down
+ALTER TABLE mobilda.held_change DROP COLUMN resolved_at;
+ALTER TABLE mobilda.held_change DROP COLUMN reason;
+ALTER TABLE mobilda.held_change DROP COLUMN previous;
+ALTER TABLE mobilda.held_change DROP COLUMN status;
@@ -0,0 +1,46 @@
+-- +goose Up
+
+CREATE TABLE mobilda.offer_override (
+  account_id             INT                                               NOT NULL,
+  offer_id               BIGINT                                            NOT NULL,
+  tracking_url           TEXT                                              CHECK (length(tracking_url) <= 15000),
+  payout_cap             DOUBLE PRECISION                                  CHECK (payout_cap >= 0),
+  disabled               BOOLEAN DEFAULT FALSE                             NOT NULL,
+  note                   TEXT                                              CHECK (length(note) <= 15000),
+  updated_by             TEXT                                              CHECK (length(updated_by) <= 255),
+  created_at             TIMESTAMP WITH TIME ZONE DEFAULT now()            NOT NULL,
+  updated_at             TIMESTAMP WITH TIME ZONE DEFAULT now()            NOT NULL,
+  CONSTRAINT offer_override_pk PRIMARY KEY (account_id, offer_id)
+);
+
+ALTER TABLE mobilda.offer_override
+  ADD CONSTRAINT offer_override_account_fk
+FOREIGN KEY (account_id)
+REFERENCES mobilda.account
+ON DELETE CASCADE;
+
+-- previous and override are states of the override before and after the change
+CREATE TABLE mobilda.offer_override_audit (
+  id                     BIGSERIAL PRIMARY KEY,
+  account_id             INT                                               NOT NULL,
+  offer_id               BIGINT                                            NOT NULL,
+  action                 TEXT                                              NOT NULL CHECK (length(action) <= 32),
+  previous               JSONB,
+  override               JSONB,
+  author                 TEXT                                              CHECK (length(author) <= 255),
+  note                   TEXT                                              CHECK (length(note) <= 15000),
+  created_at             TIMESTAMP WITH TIME ZONE DEFAULT now()            NOT NULL
+);
+
+CREATE INDEX offer_override_audit_offer_idx ON mobilda.offer_override_audit (account_id, offer_id);
+
+-- filled by the collector, offer columns keep effective values and upstream keeps overridden upstream values
+ALTER TABLE mobilda.offer ADD COLUMN overridden TEXT[];
+ALTER TABLE mobilda.offer ADD COLUMN upstream JSONB;
+
+
+-- +goose Down
+ALTER TABLE mobilda.offer DROP COLUMN upstream;
+ALTER TABLE mobilda.offer DROP COLUMN overridden;
+DROP TABLE mobilda.offer_override_audit;
+DROP TABLE mobilda.offer_override;
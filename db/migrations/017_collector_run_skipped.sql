@@ -0,0 +1,8 @@
+-- +goose Up
+
+-- offers skipped by ingest rules
+ALTER TABLE mobilda.collector_run ADD COLUMN skipped INT DEFAULT 0 NOT NULL;
+
+
+-- +goose Down
+ALTER TABLE mobilda.collector_run DROP COLUMN skipped;
@@ -0,0 +1,7 @@
+-- +goose Up
+
+-- upstream is only kept with overridden fields, offers without them are not rewritten to clear it
+UPDATE mobilda.offer SET upstream = NULL WHERE upstream IS NOT NULL AND COALESCE(cardinality(overridden), 0) = 0;
+
+
+-- +goose Down
@@ -0,0 +1,45 @@
+-- +goose Up
+
+-- existing offers are not rewritten until they change, they are linked here by the keys of model.AppOf:
+-- store link, else App Store id of a numeric package name, else lowercased package name
+CREATE TEMPORARY TABLE offer_app AS
+SELECT id AS offer_id, title, bundle_id, store_platform, package_name,
+  CASE
+    WHEN COALESCE(store_app_id, '') <> '' THEN store
+    WHEN lower(trim(package_name)) ~ '^(id)?[0-9]+$' THEN 'app_store'
+    ELSE 'package'
+  END AS store,
+  CASE
+    WHEN COALESCE(store_app_id, '') <> '' THEN store_app_id
+    WHEN lower(trim(package_name)) ~ '^(id)?[0-9]+$' THEN ltrim(lower(trim(package_name)), 'id')
+    ELSE lower(trim(package_name))
+  END AS store_app_id
+FROM mobilda.offer;
+
+INSERT INTO mobilda.app (store, store_app_id, platform, bundle_id, title)
+SELECT DISTINCT ON (store, store_app_id) store, store_app_id,
+  CASE WHEN store = 'app_store' THEN 'ios' ELSE NULLIF(store_platform, '') END,
+  CASE
+    WHEN COALESCE(bundle_id, '') <> '' THEN bundle_id
+    WHEN store = 'package' THEN trim(package_name)
+  END,
+  NULLIF(title, '')
+FROM offer_app
+WHERE store_app_id <> ''
+ORDER BY store, store_app_id, offer_id DESC
+ON CONFLICT (store, store_app_id) DO NOTHING;
+
+UPDATE mobilda.offer SET app_id = app.id
+FROM offer_app, mobilda.app app
+WHERE offer.id = offer_app.offer_id AND app.store = offer_app.store AND app.store_app_id = offer_app.store_app_id;
+
+DROP TABLE offer_app;
+
+
+-- numeric packages were keyed as packages before, their apps are left without offers
+DELETE FROM mobilda.app app
+WHERE app.store = 'package' AND app.store_app_id ~ '^(id)?[0-9]+$'
+  AND NOT EXISTS (SELECT 1 FROM mobilda.offer WHERE offer.app_id = app.id);
+
+
+-- +goose Down
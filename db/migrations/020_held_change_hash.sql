@@ -0,0 +1,8 @@
+-- +goose Up
+
+-- the offer is stored as json without its hash, approved changes are written with the hash of the held run
+ALTER TABLE mobilda.held_change ADD COLUMN hash TEXT;
+
+
+-- +goose Down
+ALTER TABLE mobilda.held_change DROP COLUMN hash;
@@ -6,5 +6,47 @@ var (
 	ErrApiParams = errors.New("Api params are invalid")
 	ErrLimitPage = errors.New("Limit and Page must be greater than zero")
 
+	ErrApiUnavailable = errors.New("Mobilda api is unavailable, retries exceeded")
+
 	ErrCantGetAccountsFromConfig = errors.New("Cannot get accounts from config")
+
+	ErrCollectorNotFound  = errors.New("Collector not found")
+	ErrCollectorIsRunning = errors.New("Collector is already running")
+	ErrJobNotFound        = errors.New("Job not found")
+
+	ErrCollectorNotSchedulable = errors.New("Collector schedule cannot be changed")
+
+	ErrAccountNotFound      = errors.New("Account not found")
+	ErrAccountsNotSupported = errors.New("Collector does not support running for selected accounts")
+
+	ErrWebhookNotFound    = errors.New("Webhook not found")
+	ErrWebhookUrlInvalid  = errors.New("Webhook url must be an absolute http or https url")
+	ErrWebhookTypeInvalid = errors.New("Webhook event type must be one of created, updated, stopped, reactivated")
+	ErrDeliveryNotFound   = errors.New("Webhook delivery not found")
+
+	ErrSinkUnknown       = errors.New("Sink type must be one of postgres, ndjson, stdout")
+	ErrSinkPathRequired  = errors.New("Sink path is required for ndjson sink")
+	ErrSinkPostgresFirst = errors.New("Postgres must be the first and the only postgres sink")
+
+	ErrOfferExists = errors.New("Offer already exists")
+	ErrRunNotFound = errors.New("Collector run not found")
+
+	ErrMappingNotFound        = errors.New("Category mapping not found")
+	ErrMappingVerticalMissing = errors.New("Category mapping vertical is required")
+
+	ErrAppNotFound = errors.New("App not found")
+
+	ErrOverrideNotFound      = errors.New("Offer override not found")
+	ErrOverrideEmpty         = errors.New("Offer override must set tracking_url, payout_cap or disabled")
+	ErrOverridePayoutCap     = errors.New("Offer override payout_cap must not be negative")
+	ErrOverrideAuthorMissing = errors.New("Offer override author is required")
+
+	ErrAnomalyActionUnknown = errors.New("Anomaly action must be one of hold, partial")
+	ErrAnomalyFieldUnknown  = errors.New("Anomaly field must be one of title, package_name, preview_url, tracking_domain, payout, currency, business_model, countries")
+
+	ErrHoldNotFound       = errors.New("Held run not found")
+	ErrHoldResolved       = errors.New("Held run is already resolved or superseded")
+	ErrHeldChangeNotFound = errors.New("Pending change of the held run not found")
+
+	ErrIngestPayoutInvalid = errors.New("Ingest rule min_payout must not be negative")
 )
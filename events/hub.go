@@ -0,0 +1,132 @@
+package events
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"mobilda/model"
+)
+
+// subscriber channel buffer, a subscriber which falls behind is disconnected
+const subscriptionBuffer = 1024
+
+// Filter of offer events, empty fields match everything
+type Filter struct {
+	Accounts  []int
+	Countries []string
+	Types     []string
+}
+
+func (f Filter) Match(e model.OfferEvent) bool {
+	if len(f.Accounts) > 0 && !containsInt(f.Accounts, e.AccountId) {
+		return false
+	}
+	if len(f.Types) > 0 && !containsString(f.Types, e.ChangeType) {
+		return false
+	}
+	if len(f.Countries) > 0 {
+		if e.Offer == nil {
+			return false
+		}
+		for _, c := range f.Countries {
+			for _, oc := range e.Offer.Countries {
+				if strings.EqualFold(c, oc) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Subscription receives events matching its filter until it is closed
+type Subscription struct {
+	C      <-chan model.OfferEvent
+	c      chan model.OfferEvent
+	filter Filter
+	closed bool
+}
+
+// Hub delivers offer events committed by the collector to subscribers
+type Hub struct {
+	lock sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: map[*Subscription]struct{}{}}
+}
+
+func (h *Hub) Subscribe(f Filter) *Subscription {
+	c := make(chan model.OfferEvent, subscriptionBuffer)
+	s := &Subscription{C: c, c: c, filter: f}
+
+	h.lock.Lock()
+	h.subs[s] = struct{}{}
+	h.lock.Unlock()
+
+	return s
+}
+
+func (h *Hub) Unsubscribe(s *Subscription) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.close(s)
+}
+
+// Publish sends events to matching subscribers without blocking.
+// A subscriber with full buffer is closed, it should resume from the last received seq.
+func (h *Hub) Publish(events []model.OfferEvent) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for s := range h.subs {
+		for _, e := range events {
+			if !s.filter.Match(e) {
+				continue
+			}
+			select {
+			case s.c <- e:
+			default:
+				h.close(s)
+			}
+			if s.closed {
+				break
+			}
+		}
+	}
+}
+
+// close must be called under lock
+func (h *Hub) close(s *Subscription) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	delete(h.subs, s)
+	close(s.c)
+}
+
+func containsInt(list []int, v int) bool {
+	for _, i := range list {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
+
+func FromContext(ctx context.Context, key string) *Hub {
+	return ctx.Value(key).(*Hub)
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
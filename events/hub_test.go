@@ -0,0 +1,61 @@
+package events
+
+import (
+	"testing"
+
+	"mobilda/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func event(seq int64, account int, countries ...string) model.OfferEvent {
+	return model.OfferEvent{
+		OfferChange: model.OfferChange{Seq: seq, AccountId: account},
+		Offer:       &model.Offer{AccountId: account, Countries: countries},
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	assert.True(t, Filter{}.Match(event(1, 1)))
+	assert.True(t, Filter{Accounts: []int{1, 2}}.Match(event(1, 2)))
+	assert.False(t, Filter{Accounts: []int{1}}.Match(event(1, 2)))
+	assert.True(t, Filter{Countries: []string{"us"}}.Match(event(1, 1, "GB", "US")))
+	assert.False(t, Filter{Countries: []string{"DE"}}.Match(event(1, 1, "GB", "US")))
+	assert.False(t, Filter{Countries: []string{"DE"}}.Match(model.OfferEvent{}))
+
+	stopped := event(1, 1)
+	stopped.ChangeType = model.OfferChangeStopped
+	assert.True(t, Filter{Types: []string{model.OfferChangeStopped}}.Match(stopped))
+	assert.False(t, Filter{Types: []string{model.OfferChangeCreated}}.Match(stopped))
+}
+
+func TestHub_Publish(t *testing.T) {
+	hub := NewHub()
+	all := hub.Subscribe(Filter{})
+	us := hub.Subscribe(Filter{Countries: []string{"US"}})
+
+	hub.Publish([]model.OfferEvent{event(1, 1, "US"), event(2, 1, "GB")})
+
+	assert.Equal(t, int64(1), (<-all.C).Seq)
+	assert.Equal(t, int64(2), (<-all.C).Seq)
+	assert.Equal(t, int64(1), (<-us.C).Seq)
+	assert.Len(t, us.C, 0)
+
+	hub.Unsubscribe(us)
+	_, ok := <-us.C
+	assert.False(t, ok)
+}
+
+func TestHub_SlowSubscriberIsClosed(t *testing.T) {
+	hub := NewHub()
+	s := hub.Subscribe(Filter{})
+
+	events := make([]model.OfferEvent, subscriptionBuffer+1)
+	hub.Publish(events)
+
+	count := 0
+	for range s.C {
+		count++
+	}
+	assert.Equal(t, subscriptionBuffer, count)
+}
@@ -0,0 +1,81 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"mobilda/model"
+)
+
+// arrays are joined with this separator in csv cells
+const csvListSeparator = "|"
+
+var csvHeader = []string{
+	"account_id", "offer_id", "is_active", "status_changed_at", "package_name", "title", "description",
+	"domain", "preview_url", "tracking_url", "business_model", "rate", "payout", "currency", "thumbnail",
+	"countries", "cities", "categories", "languages", "black_list_sources", "mobile_support",
+	"allowed_devices", "min_os_version", "app_price", "app_rating", "content_rating", "developer",
+	"developer_website", "promo_video", "cap_enable", "cap_amount", "cap_current_amount", "cap_frequency",
+	"capping_field", "capping_timeframe",
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCsvWriter(w io.Writer) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w)}
+	return cw, cw.w.Write(csvHeader)
+}
+
+func (cw *csvWriter) Write(o model.Offer) error {
+	return cw.w.Write([]string{
+		strconv.Itoa(o.AccountId),
+		strconv.FormatUint(o.Id, 10),
+		strconv.FormatBool(o.IsActive),
+		o.StatusChangedAt.Format(time.RFC3339),
+		o.PackageName,
+		o.Title,
+		o.Description,
+		o.Domain,
+		o.PreviewUrl,
+		o.TrackingUrl,
+		o.BusinessModel,
+		o.Rate,
+		strconv.FormatFloat(o.Payout, 'f', -1, 64),
+		o.Currency,
+		o.Thumbnail,
+		strings.Join(o.Countries, csvListSeparator),
+		strings.Join(o.Cities, csvListSeparator),
+		strings.Join(o.Categories, csvListSeparator),
+		strings.Join(o.Languages, csvListSeparator),
+		strings.Join(o.BlackListSources, csvListSeparator),
+		o.MobileSupport,
+		strings.Join(o.AllowedDevices, csvListSeparator),
+		strings.Join(o.MinOsVersion, csvListSeparator),
+		o.AppPrice,
+		o.AppRating,
+		o.ContentRating,
+		o.Developer,
+		o.DeveloperWebsite,
+		o.PromoVideo,
+		o.CapEnable,
+		o.CapAmount,
+		o.CapCurrentAmount,
+		o.CapFrequency,
+		o.CappingField,
+		o.CappingTimeframe,
+	})
+}
+
+func (cw *csvWriter) Flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *csvWriter) Close() error {
+	return cw.Flush()
+}
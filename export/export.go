@@ -0,0 +1,95 @@
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"mobilda/model"
+	"mobilda/query"
+
+	"bitbucket.org/mobio/go-dbmanager"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatNDJSON  = "ndjson"
+	FormatParquet = "parquet"
+
+	// offers are read from the database in batches of this size
+	BatchSize = 1000
+)
+
+// Writer writes offers in one of export formats
+type Writer interface {
+	Write(offer model.Offer) error
+	// Flush writes buffered offers to the underlying writer
+	Flush() error
+	// Close flushes offers and writes format footer if any, the underlying writer is not closed
+	Close() error
+}
+
+// CheckFormat returns an error for unknown export formats
+func CheckFormat(format string) error {
+	switch format {
+	case FormatCSV, FormatNDJSON, FormatParquet:
+		return nil
+	}
+	return fmt.Errorf("Unknown export format %q", format)
+}
+
+// NewWriter returns writer of the format
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	if err := CheckFormat(format); err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatCSV:
+		return newCsvWriter(w)
+	case FormatNDJSON:
+		return newNdjsonWriter(w), nil
+	}
+	return newParquetWriter(w)
+}
+
+// ContentType returns http content type of the format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	}
+	return "application/octet-stream"
+}
+
+// Export writes offers matched by the filter, ignoring its limit and cursor.
+// Offers are read in batches, so memory does not depend on the number of offers.
+func Export(db *dbmanager.DbManager, filter *query.OfferFilter, w Writer) (int, error) {
+	f := *filter
+	f.Limit = BatchSize
+	f.Cursor = nil
+
+	total := 0
+	for {
+		offers := []model.Offer{}
+		if err := f.Page(db.Model(&offers)).Select(); err != nil {
+			return total, err
+		}
+
+		page, next := f.NextPage(offers)
+		for _, offer := range page {
+			if err := w.Write(offer); err != nil {
+				return total, err
+			}
+			total++
+		}
+		if err := w.Flush(); err != nil {
+			return total, err
+		}
+
+		if next == "" {
+			return total, w.Close()
+		}
+		f.Cursor = f.CursorOf(page[len(page)-1])
+	}
+}
@@ -0,0 +1,83 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"mobilda/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testOffer = model.Offer{
+	Id:              42,
+	AccountId:       2,
+	Title:           "Some, app",
+	Rate:            "1.5",
+	Payout:          1.5,
+	Countries:       []string{"US", "GB"},
+	AllowedDevices:  []string{"Android"},
+	IsActive:        true,
+	StatusChangedAt: time.Date(2017, 5, 10, 12, 0, 0, 0, time.UTC),
+}
+
+func TestCsvWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(FormatCSV, buf)
+	assert.Nil(t, err)
+	assert.Nil(t, w.Write(testOffer))
+	assert.Nil(t, w.Close())
+
+	records, err := csv.NewReader(buf).ReadAll()
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, csvHeader, records[0])
+
+	row := map[string]string{}
+	for i, name := range csvHeader {
+		row[name] = records[1][i]
+	}
+	assert.Equal(t, "42", row["offer_id"])
+	assert.Equal(t, "Some, app", row["title"])
+	assert.Equal(t, "US|GB", row["countries"])
+	assert.Equal(t, "", row["cities"])
+	assert.Equal(t, "2017-05-10T12:00:00Z", row["status_changed_at"])
+}
+
+func TestNdjsonWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(FormatNDJSON, buf)
+	assert.Nil(t, err)
+	assert.Nil(t, w.Write(testOffer))
+	assert.Nil(t, w.Write(testOffer))
+	assert.Nil(t, w.Close())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	offer := model.Offer{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &offer))
+	assert.Equal(t, testOffer.Countries, offer.Countries)
+}
+
+func TestParquetWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(FormatParquet, buf)
+	assert.Nil(t, err)
+	assert.Nil(t, w.Write(testOffer))
+	assert.Nil(t, w.Close())
+
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("PAR1")))
+	assert.True(t, bytes.HasSuffix(buf.Bytes(), []byte("PAR1")))
+}
+
+func TestNewWriter_UnknownFormat(t *testing.T) {
+	_, err := NewWriter("xlsx", &bytes.Buffer{})
+	assert.NotNil(t, err)
+	assert.NotNil(t, CheckFormat("xlsx"))
+	assert.Nil(t, CheckFormat(FormatParquet))
+}
@@ -0,0 +1,32 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"mobilda/model"
+)
+
+type ndjsonWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newNdjsonWriter(w io.Writer) *ndjsonWriter {
+	buf := bufio.NewWriter(w)
+	return &ndjsonWriter{buf: buf, enc: json.NewEncoder(buf)}
+}
+
+// Write writes offer as a single json line, arrays are kept as json arrays
+func (nw *ndjsonWriter) Write(o model.Offer) error {
+	return nw.enc.Encode(o)
+}
+
+func (nw *ndjsonWriter) Flush() error {
+	return nw.buf.Flush()
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nw.Flush()
+}
@@ -0,0 +1,116 @@
+package export
+
+import (
+	"io"
+
+	"mobilda/model"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// rows are buffered in memory until the row group is full
+const parquetRowGroupSize = 8 * 1024 * 1024
+
+// parquetOffer is a parquet row of the offer, arrays are stored as lists of strings
+type parquetOffer struct {
+	AccountId        int32    `parquet:"name=account_id, type=INT32"`
+	OfferId          int64    `parquet:"name=offer_id, type=INT64"`
+	IsActive         bool     `parquet:"name=is_active, type=BOOLEAN"`
+	StatusChangedAt  int64    `parquet:"name=status_changed_at, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	PackageName      string   `parquet:"name=package_name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Title            string   `parquet:"name=title, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Description      string   `parquet:"name=description, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Domain           string   `parquet:"name=domain, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PreviewUrl       string   `parquet:"name=preview_url, type=BYTE_ARRAY, convertedtype=UTF8"`
+	TrackingUrl      string   `parquet:"name=tracking_url, type=BYTE_ARRAY, convertedtype=UTF8"`
+	BusinessModel    string   `parquet:"name=business_model, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Rate             string   `parquet:"name=rate, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Payout           float64  `parquet:"name=payout, type=DOUBLE"`
+	Currency         string   `parquet:"name=currency, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Thumbnail        string   `parquet:"name=thumbnail, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Countries        []string `parquet:"name=countries, type=LIST, valuetype=BYTE_ARRAY, valueconvertedtype=UTF8"`
+	Cities           []string `parquet:"name=cities, type=LIST, valuetype=BYTE_ARRAY, valueconvertedtype=UTF8"`
+	Categories       []string `parquet:"name=categories, type=LIST, valuetype=BYTE_ARRAY, valueconvertedtype=UTF8"`
+	Languages        []string `parquet:"name=languages, type=LIST, valuetype=BYTE_ARRAY, valueconvertedtype=UTF8"`
+	BlackListSources []string `parquet:"name=black_list_sources, type=LIST, valuetype=BYTE_ARRAY, valueconvertedtype=UTF8"`
+	MobileSupport    string   `parquet:"name=mobile_support, type=BYTE_ARRAY, convertedtype=UTF8"`
+	AllowedDevices   []string `parquet:"name=allowed_devices, type=LIST, valuetype=BYTE_ARRAY, valueconvertedtype=UTF8"`
+	MinOsVersion     []string `parquet:"name=min_os_version, type=LIST, valuetype=BYTE_ARRAY, valueconvertedtype=UTF8"`
+	AppPrice         string   `parquet:"name=app_price, type=BYTE_ARRAY, convertedtype=UTF8"`
+	AppRating        string   `parquet:"name=app_rating, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ContentRating    string   `parquet:"name=content_rating, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Developer        string   `parquet:"name=developer, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DeveloperWebsite string   `parquet:"name=developer_website, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PromoVideo       string   `parquet:"name=promo_video, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CapEnable        string   `parquet:"name=cap_enable, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CapAmount        string   `parquet:"name=cap_amount, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CapCurrentAmount string   `parquet:"name=cap_current_amount, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CapFrequency     string   `parquet:"name=cap_frequency, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CappingField     string   `parquet:"name=capping_field, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CappingTimeframe string   `parquet:"name=capping_timeframe, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+type parquetWriter struct {
+	pw *writer.ParquetWriter
+}
+
+func newParquetWriter(w io.Writer) (*parquetWriter, error) {
+	pw, err := writer.NewParquetWriterFromWriter(w, new(parquetOffer), 1)
+	if err != nil {
+		return nil, err
+	}
+	pw.RowGroupSize = parquetRowGroupSize
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+
+	return &parquetWriter{pw: pw}, nil
+}
+
+func (pw *parquetWriter) Write(o model.Offer) error {
+	return pw.pw.Write(parquetOffer{
+		AccountId:        int32(o.AccountId),
+		OfferId:          int64(o.Id),
+		IsActive:         o.IsActive,
+		StatusChangedAt:  o.StatusChangedAt.UnixNano() / 1e6,
+		PackageName:      o.PackageName,
+		Title:            o.Title,
+		Description:      o.Description,
+		Domain:           o.Domain,
+		PreviewUrl:       o.PreviewUrl,
+		TrackingUrl:      o.TrackingUrl,
+		BusinessModel:    o.BusinessModel,
+		Rate:             o.Rate,
+		Payout:           o.Payout,
+		Currency:         o.Currency,
+		Thumbnail:        o.Thumbnail,
+		Countries:        o.Countries,
+		Cities:           o.Cities,
+		Categories:       o.Categories,
+		Languages:        o.Languages,
+		BlackListSources: o.BlackListSources,
+		MobileSupport:    o.MobileSupport,
+		AllowedDevices:   o.AllowedDevices,
+		MinOsVersion:     o.MinOsVersion,
+		AppPrice:         o.AppPrice,
+		AppRating:        o.AppRating,
+		ContentRating:    o.ContentRating,
+		Developer:        o.Developer,
+		DeveloperWebsite: o.DeveloperWebsite,
+		PromoVideo:       o.PromoVideo,
+		CapEnable:        o.CapEnable,
+		CapAmount:        o.CapAmount,
+		CapCurrentAmount: o.CapCurrentAmount,
+		CapFrequency:     o.CapFrequency,
+		CappingField:     o.CappingField,
+		CappingTimeframe: o.CappingTimeframe,
+	})
+}
+
+// Flush is a no-op, rows are written when the row group is full
+func (pw *parquetWriter) Flush() error {
+	return nil
+}
+
+func (pw *parquetWriter) Close() error {
+	return pw.pw.WriteStop()
+}
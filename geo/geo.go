@@ -0,0 +1,140 @@
+// Package geo normalizes countries to ISO-3166 alpha-2 codes, languages to ISO-639-1 codes
+// and city names to a single spelling, using the embedded reference tables
+package geo
+
+import (
+	"strings"
+	"unicode"
+)
+
+var (
+	countries = parseTable(countryTable, strings.ToUpper)
+	languages = parseTable(languageTable, strings.ToLower)
+)
+
+// parseTable returns codes by lowercased code, alternative codes, names and aliases
+func parseTable(table string, code func(string) string) map[string]string {
+	index := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(table), "\n") {
+		fields := strings.Split(line, "|")
+		c := code(fields[0])
+		index[key(fields[0])] = c
+		for _, alt := range strings.Fields(fields[1]) {
+			index[key(alt)] = c
+		}
+		for _, name := range fields[2:] {
+			index[key(name)] = c
+		}
+	}
+	return index
+}
+
+func key(value string) string {
+	return strings.ToLower(strings.Join(strings.Fields(value), " "))
+}
+
+// Country returns the alpha-2 code of a country code, name or locale like "en_US"
+func Country(value string) (string, bool) {
+	if c, ok := countries[key(value)]; ok {
+		return c, true
+	}
+	if lang, region, ok := locale(value); ok {
+		if _, isLang := languages[lang]; isLang {
+			c, ok := countries[region]
+			return c, ok
+		}
+	}
+	return "", false
+}
+
+// Language returns the ISO-639-1 code of a language code, name or locale like "en-US"
+func Language(value string) (string, bool) {
+	if c, ok := languages[key(value)]; ok {
+		return c, true
+	}
+	if lang, _, ok := locale(value); ok {
+		c, ok := languages[lang]
+		return c, ok
+	}
+	return "", false
+}
+
+// City returns the city name with collapsed spaces and capitalized words, e.g. "NEW  york" is "New York"
+func City(value string) string {
+	words := strings.Fields(strings.ToLower(value))
+	for i, w := range words {
+		runes := []rune(w)
+		capitalize := true
+		for j, r := range runes {
+			if capitalize {
+				runes[j] = unicode.ToUpper(r)
+			}
+			capitalize = r == '-' || r == '.'
+		}
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
+
+// locale splits values like "en_US", "en-us" or "zh-Hans-CN" into lowercased language and last subtag
+func locale(value string) (string, string, bool) {
+	parts := strings.FieldsFunc(strings.TrimSpace(value), func(r rune) bool { return r == '-' || r == '_' })
+	if len(parts) < 2 {
+		return "", "", false
+	}
+	return strings.ToLower(parts[0]), strings.ToLower(parts[len(parts)-1]), true
+}
+
+// Countries returns distinct alpha-2 codes of the values and the values which are not known
+func Countries(values []string) (codes []string, unknown []string) {
+	return normalizeList(values, Country)
+}
+
+// Languages returns distinct ISO-639-1 codes of the values and the values which are not known
+func Languages(values []string) (codes []string, unknown []string) {
+	return normalizeList(values, Language)
+}
+
+// Cities returns distinct normalized city names
+func Cities(values []string) []string {
+	cities, _ := normalizeList(values, func(v string) (string, bool) { return City(v), true })
+	return cities
+}
+
+// Codes returns a copy of the values with known ones replaced by their codes and unknown ones kept as is
+func Codes(values []string, code func(string) (string, bool)) []string {
+	if values == nil {
+		return nil
+	}
+	codes := make([]string, len(values))
+	for i, v := range values {
+		if c, ok := code(v); ok {
+			v = c
+		}
+		codes[i] = v
+	}
+	return codes
+}
+
+func normalizeList(values []string, normalize func(string) (string, bool)) ([]string, []string) {
+	if values == nil {
+		return nil, nil
+	}
+	codes, unknown := []string{}, []string(nil)
+	seen := map[string]bool{}
+	for _, v := range values {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+		c, ok := normalize(v)
+		if !ok {
+			unknown = append(unknown, v)
+			continue
+		}
+		if !seen[c] {
+			seen[c] = true
+			codes = append(codes, c)
+		}
+	}
+	return codes, unknown
+}
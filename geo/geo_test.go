@@ -0,0 +1,72 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountry(t *testing.T) {
+	cases := map[string]string{
+		"US":                       "US",
+		"us":                       "US",
+		"USA":                      "US",
+		"United States of America": "US",
+		"UK":                       "GB",
+		"  united   kingdom ":      "GB",
+		"Deutschland":              "DE",
+		"en_GB":                    "GB",
+		"pt-BR":                    "BR",
+		"Côte d'Ivoire":            "CI",
+	}
+	for value, expected := range cases {
+		c, ok := Country(value)
+		assert.True(t, ok, value)
+		assert.Equal(t, expected, c, value)
+	}
+
+	for _, value := range []string{"", "Atlantis", "XX", "en-XX"} {
+		_, ok := Country(value)
+		assert.False(t, ok, value)
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	cases := map[string]string{
+		"en":      "en",
+		"EN":      "en",
+		"eng":     "en",
+		"English": "en",
+		"ger":     "de",
+		"en_US":   "en",
+		"zh-Hans": "zh",
+		"Español": "es",
+	}
+	for value, expected := range cases {
+		c, ok := Language(value)
+		assert.True(t, ok, value)
+		assert.Equal(t, expected, c, value)
+	}
+
+	_, ok := Language("Klingon")
+	assert.False(t, ok)
+}
+
+func TestLists(t *testing.T) {
+	codes, unknown := Countries([]string{"US", "usa", "UK", "", "Narnia"})
+	assert.Equal(t, []string{"US", "GB"}, codes)
+	assert.Equal(t, []string{"Narnia"}, unknown)
+
+	codes, unknown = Languages(nil)
+	assert.Nil(t, codes)
+	assert.Nil(t, unknown)
+
+	assert.Equal(t, []string{"New York", "Winston-Salem"}, Cities([]string{"NEW  york", "new york", "winston-salem"}))
+}
+
+func TestCodes(t *testing.T) {
+	values := []string{"usa", "Narnia", "DE"}
+	assert.Equal(t, []string{"US", "Narnia", "DE"}, Codes(values, Country))
+	assert.Equal(t, []string{"usa", "Narnia", "DE"}, values)
+	assert.Nil(t, Codes(nil, Language))
+}
@@ -0,0 +1,189 @@
+package geo
+
+// languageTable is ISO-639-1 code, ISO-639-2 codes, english name and common aliases
+const languageTable = `
+aa|aar|Afar
+ab|abk|Abkhazian
+ae|ave|Avestan
+af|afr|Afrikaans
+ak|aka|Akan
+am|amh|Amharic
+an|arg|Aragonese
+ar|ara|Arabic
+as|asm|Assamese
+av|ava|Avaric
+ay|aym|Aymara
+az|aze|Azerbaijani
+ba|bak|Bashkir
+be|bel|Belarusian
+bg|bul|Bulgarian
+bh|bih|Bihari
+bi|bis|Bislama
+bm|bam|Bambara
+bn|ben|Bengali|Bangla
+bo|bod tib|Tibetan
+br|bre|Breton
+bs|bos|Bosnian
+ca|cat|Catalan
+ce|che|Chechen
+ch|cha|Chamorro
+co|cos|Corsican
+cr|cre|Cree
+cs|ces cze|Czech
+cu|chu|Church Slavic
+cv|chv|Chuvash
+cy|cym wel|Welsh
+da|dan|Danish
+de|deu ger|German|Deutsch
+dv|div|Divehi|Dhivehi
+dz|dzo|Dzongkha
+ee|ewe|Ewe
+el|ell gre|Greek
+en|eng|English
+eo|epo|Esperanto
+es|spa|Spanish|Español|Castilian
+et|est|Estonian
+eu|eus baq|Basque
+fa|fas per|Persian|Farsi
+ff|ful|Fulah
+fi|fin|Finnish
+fj|fij|Fijian
+fo|fao|Faroese
+fr|fra fre|French|Français
+fy|fry|Western Frisian|Frisian
+ga|gle|Irish
+gd|gla|Gaelic|Scottish Gaelic
+gl|glg|Galician
+gn|grn|Guarani
+gu|guj|Gujarati
+gv|glv|Manx
+ha|hau|Hausa
+he|heb|Hebrew|iw
+hi|hin|Hindi
+ho|hmo|Hiri Motu
+hr|hrv|Croatian
+ht|hat|Haitian|Haitian Creole
+hu|hun|Hungarian
+hy|hye arm|Armenian
+hz|her|Herero
+ia|ina|Interlingua
+id|ind|Indonesian|Bahasa Indonesia|in
+ie|ile|Interlingue
+ig|ibo|Igbo
+ii|iii|Sichuan Yi
+ik|ipk|Inupiaq
+io|ido|Ido
+is|isl ice|Icelandic
+it|ita|Italian|Italiano
+iu|iku|Inuktitut
+ja|jpn|Japanese
+jv|jav|Javanese
+ka|kat geo|Georgian
+kg|kon|Kongo
+ki|kik|Kikuyu
+kj|kua|Kuanyama
+kk|kaz|Kazakh
+kl|kal|Kalaallisut|Greenlandic
+km|khm|Khmer|Central Khmer
+kn|kan|Kannada
+ko|kor|Korean
+kr|kau|Kanuri
+ks|kas|Kashmiri
+ku|kur|Kurdish
+kv|kom|Komi
+kw|cor|Cornish
+ky|kir|Kirghiz|Kyrgyz
+la|lat|Latin
+lb|ltz|Luxembourgish
+lg|lug|Ganda
+li|lim|Limburgish
+ln|lin|Lingala
+lo|lao|Lao
+lt|lit|Lithuanian
+lu|lub|Luba-Katanga
+lv|lav|Latvian
+mg|mlg|Malagasy
+mh|mah|Marshallese
+mi|mri mao|Maori
+mk|mkd mac|Macedonian
+ml|mal|Malayalam
+mn|mon|Mongolian
+mr|mar|Marathi
+ms|msa may|Malay|Bahasa Melayu
+mt|mlt|Maltese
+my|mya bur|Burmese
+na|nau|Nauru
+nb|nob|Norwegian Bokmal|Norwegian Bokmål|Bokmal
+nd|nde|North Ndebele
+ne|nep|Nepali
+ng|ndo|Ndonga
+nl|nld dut|Dutch|Flemish|Nederlands
+nn|nno|Norwegian Nynorsk|Nynorsk
+no|nor|Norwegian
+nr|nbl|South Ndebele
+nv|nav|Navajo
+ny|nya|Chichewa|Nyanja
+oc|oci|Occitan
+oj|oji|Ojibwa
+om|orm|Oromo
+or|ori|Oriya|Odia
+os|oss|Ossetian
+pa|pan|Punjabi|Panjabi
+pi|pli|Pali
+pl|pol|Polish|Polski
+ps|pus|Pashto|Pushto
+pt|por|Portuguese|Português
+qu|que|Quechua
+rm|roh|Romansh
+rn|run|Rundi|Kirundi
+ro|ron rum|Romanian|Moldavian
+ru|rus|Russian|Русский
+rw|kin|Kinyarwanda
+sa|san|Sanskrit
+sc|srd|Sardinian
+sd|snd|Sindhi
+se|sme|Northern Sami
+sg|sag|Sango
+si|sin|Sinhala|Sinhalese
+sk|slk slo|Slovak
+sl|slv|Slovenian|Slovene
+sm|smo|Samoan
+sn|sna|Shona
+so|som|Somali
+sq|sqi alb|Albanian
+sr|srp|Serbian
+ss|ssw|Swati
+st|sot|Southern Sotho|Sesotho
+su|sun|Sundanese
+sv|swe|Swedish|Svenska
+sw|swa|Swahili
+ta|tam|Tamil
+te|tel|Telugu
+tg|tgk|Tajik
+th|tha|Thai
+ti|tir|Tigrinya
+tk|tuk|Turkmen
+tl|tgl fil|Tagalog|Filipino
+tn|tsn|Tswana
+to|ton|Tonga|Tongan
+tr|tur|Turkish|Türkçe
+ts|tso|Tsonga
+tt|tat|Tatar
+tw|twi|Twi
+ty|tah|Tahitian
+ug|uig|Uighur|Uyghur
+uk|ukr|Ukrainian|Українська
+ur|urd|Urdu
+uz|uzb|Uzbek
+ve|ven|Venda
+vi|vie|Vietnamese|Tiếng Việt
+vo|vol|Volapuk|Volapük
+wa|wln|Walloon
+wo|wol|Wolof
+xh|xho|Xhosa
+yi|yid|Yiddish|ji
+yo|yor|Yoruba
+za|zha|Zhuang
+zh|zho chi|Chinese|Mandarin|中文
+zu|zul|Zulu
+`
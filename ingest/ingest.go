@@ -0,0 +1,92 @@
+// Package ingest filters upstream offers of an account sync before they are stored
+package ingest
+
+import (
+	"context"
+	"strings"
+
+	"mobilda/errors"
+	"mobilda/model"
+)
+
+// Reasons an offer is skipped
+const (
+	ReasonBusinessModel = "business_model"
+	ReasonCurrency      = "currency"
+	ReasonSource        = "blacklisted_source"
+	ReasonPayout        = "min_payout"
+)
+
+// Rule is an item of the ingest section of app.yaml. Rules without account apply to all accounts.
+// Lists are matched case insensitive, empty lists and zero payout pass every offer.
+type Rule struct {
+	AccountId int `mapstructure:"account_id"`
+	// IncludeBusinessModels keeps only offers of the business models
+	IncludeBusinessModels []string `mapstructure:"include_business_models"`
+	// ExcludeBusinessModels skips offers of the business models
+	ExcludeBusinessModels []string `mapstructure:"exclude_business_models"`
+	// IncludeCurrencies keeps only offers paid in the currencies
+	IncludeCurrencies []string `mapstructure:"include_currencies"`
+	// Sources are our traffic sources, offers blacklisting any of them are skipped
+	Sources []string `mapstructure:"sources"`
+	// MinPayout skips offers paying less, in the offer currency
+	MinPayout float64 `mapstructure:"min_payout"`
+}
+
+// Rules are ingest rules of all accounts, an offer is stored if it passes every rule of its account
+type Rules []Rule
+
+// Validate checks rule payouts
+func (r Rules) Validate() error {
+	for _, rule := range r {
+		if rule.MinPayout < 0 {
+			return errors.ErrIngestPayoutInvalid
+		}
+	}
+	return nil
+}
+
+// Skip returns the reason the offer is skipped, empty if the offer passes all rules of its account
+func (r Rules) Skip(offer model.Offer) string {
+	for _, rule := range r {
+		if rule.AccountId != 0 && rule.AccountId != offer.AccountId {
+			continue
+		}
+		if reason := rule.skip(offer); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+func (rule Rule) skip(offer model.Offer) string {
+	switch {
+	case len(rule.IncludeBusinessModels) > 0 && !contains(rule.IncludeBusinessModels, offer.BusinessModel),
+		contains(rule.ExcludeBusinessModels, offer.BusinessModel):
+		return ReasonBusinessModel
+	case len(rule.IncludeCurrencies) > 0 && !contains(rule.IncludeCurrencies, offer.Currency):
+		return ReasonCurrency
+	case rule.MinPayout > 0 && offer.Payout < rule.MinPayout:
+		return ReasonPayout
+	}
+	for _, source := range offer.BlackListSources {
+		if contains(rule.Sources, source) {
+			return ReasonSource
+		}
+	}
+	return ""
+}
+
+func contains(list []string, value string) bool {
+	value = strings.TrimSpace(value)
+	for _, v := range list {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return true
+		}
+	}
+	return false
+}
+
+func FromContext(ctx context.Context, key string) Rules {
+	return ctx.Value(key).(Rules)
+}
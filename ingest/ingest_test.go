@@ -0,0 +1,40 @@
+package ingest
+
+import (
+	"testing"
+
+	"mobilda/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules_Skip(t *testing.T) {
+	rules := Rules{
+		{ExcludeBusinessModels: []string{"cpc"}, Sources: []string{"src_1"}},
+		{AccountId: 1, IncludeCurrencies: []string{"USD"}, MinPayout: 0.5},
+	}
+	offer := func(accountId int, businessModel, currency string, payout float64, blacklist ...string) model.Offer {
+		return model.Offer{
+			AccountId:        accountId,
+			BusinessModel:    businessModel,
+			Currency:         currency,
+			Payout:           payout,
+			BlackListSources: blacklist,
+		}
+	}
+
+	assert.Equal(t, "", rules.Skip(offer(1, "CPI", "usd", 0.5)))
+	assert.Equal(t, ReasonBusinessModel, rules.Skip(offer(1, "CPC", "USD", 1)))
+	assert.Equal(t, ReasonSource, rules.Skip(offer(2, "CPI", "EUR", 0, "src_0", " SRC_1")))
+	assert.Equal(t, ReasonCurrency, rules.Skip(offer(1, "CPI", "EUR", 1)))
+	assert.Equal(t, ReasonPayout, rules.Skip(offer(1, "CPI", "USD", 0.1)))
+
+	// account rules do not apply to other accounts
+	assert.Equal(t, "", rules.Skip(offer(2, "CPI", "EUR", 0.1)))
+	assert.Equal(t, "", Rules(nil).Skip(offer(1, "CPC", "EUR", 0)))
+}
+
+func TestRules_Validate(t *testing.T) {
+	assert.NoError(t, Rules{{MinPayout: 0.1}}.Validate())
+	assert.Error(t, Rules{{AccountId: 1, MinPayout: -1}}.Validate())
+}
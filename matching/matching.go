@@ -0,0 +1,224 @@
+// Package matching selects active offers eligible for a traffic request by their targeting
+package matching
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mobilda/geo"
+	"mobilda/model"
+	"mobilda/platform"
+)
+
+// Request is a traffic context, empty fields are not matched.
+// Country, language and city are normalized like offer targeting, e.g. "UK" matches "GB".
+// Device is parsed like offer allowed devices, e.g. "android" or "iPad".
+type Request struct {
+	Country   string
+	City      string
+	Language  string
+	Device    string
+	OsVersion string
+	Accounts  []int
+}
+
+// Index keeps active offers ordered by payout with offer positions by country.
+// Offers with empty targeting lists match any value.
+type Index struct {
+	offers     []model.Offer
+	countries  map[string][]int
+	anyCountry []int
+	// per offer sets of lowercased targeting values, nil for any
+	cities    []map[string]bool
+	languages []map[string]bool
+	targeting []platform.Targeting
+	builtAt   time.Time
+}
+
+// NewIndex builds the index of active offers
+func NewIndex(offers []model.Offer) *Index {
+	active := []model.Offer{}
+	for _, offer := range offers {
+		if offer.IsActive == model.OfferStatusActive {
+			active = append(active, offer)
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		if active[i].Payout != active[j].Payout {
+			return active[i].Payout > active[j].Payout
+		}
+		if active[i].AccountId != active[j].AccountId {
+			return active[i].AccountId < active[j].AccountId
+		}
+		return active[i].Id < active[j].Id
+	})
+
+	idx := &Index{
+		offers:    active,
+		countries: map[string][]int{},
+		cities:    make([]map[string]bool, len(active)),
+		languages: make([]map[string]bool, len(active)),
+		targeting: make([]platform.Targeting, len(active)),
+		builtAt:   time.Now(),
+	}
+	for i, offer := range active {
+		countries := set(geo.Codes(offer.Countries, geo.Country))
+		if countries == nil {
+			idx.anyCountry = append(idx.anyCountry, i)
+		}
+		for country := range countries {
+			idx.countries[country] = append(idx.countries[country], i)
+		}
+		idx.cities[i] = set(geo.Cities(offer.Cities))
+		idx.languages[i] = set(geo.Codes(offer.Languages, geo.Language))
+		idx.targeting[i] = platform.Parse(offer.AllowedDevices, offer.MinOsVersion)
+	}
+
+	return idx
+}
+
+// Match returns up to limit eligible offers ordered by payout, all of them if limit is zero
+func (idx *Index) Match(r Request, limit int) []model.Offer {
+	country, language, city := r.Country, r.Language, r.City
+	if c, ok := geo.Country(country); ok {
+		country = c
+	}
+	if l, ok := geo.Language(language); ok {
+		language = l
+	}
+	if city != "" {
+		city = geo.City(city)
+	}
+	candidates := idx.candidates(country)
+
+	device, _ := platform.ParseDevice(r.Device)
+	osVersion, _ := platform.ParseVersion(r.OsVersion)
+	accounts := map[int]bool{}
+	for _, id := range r.Accounts {
+		accounts[id] = true
+	}
+
+	matched := []model.Offer{}
+	for _, i := range candidates {
+		if len(accounts) > 0 && !accounts[idx.offers[i].AccountId] {
+			continue
+		}
+		if !allows(idx.cities[i], city) || !allows(idx.languages[i], language) {
+			continue
+		}
+		if !idx.targeting[i].Allows(device, osVersion) {
+			continue
+		}
+		matched = append(matched, idx.offers[i])
+		if limit > 0 && len(matched) == limit {
+			break
+		}
+	}
+
+	return matched
+}
+
+// Len returns the number of indexed offers
+func (idx *Index) Len() int {
+	return len(idx.offers)
+}
+
+func (idx *Index) BuiltAt() time.Time {
+	return idx.builtAt
+}
+
+// candidates returns positions of offers targeting the country or any country, in payout order
+func (idx *Index) candidates(country string) []int {
+	if country == "" {
+		all := make([]int, len(idx.offers))
+		for i := range all {
+			all[i] = i
+		}
+		return all
+	}
+
+	targeted := idx.countries[normalize(country)]
+	merged := make([]int, 0, len(targeted)+len(idx.anyCountry))
+	i, j := 0, 0
+	for i < len(targeted) || j < len(idx.anyCountry) {
+		if j == len(idx.anyCountry) || (i < len(targeted) && targeted[i] < idx.anyCountry[j]) {
+			merged = append(merged, targeted[i])
+			i++
+		} else {
+			merged = append(merged, idx.anyCountry[j])
+			j++
+		}
+	}
+	return merged
+}
+
+// Loader returns offers to index
+type Loader func() ([]model.Offer, error)
+
+// Matcher holds the current index, which is rebuilt by Refresh
+type Matcher struct {
+	lock  sync.RWMutex
+	load  Loader
+	index *Index
+}
+
+func NewMatcher(load Loader) *Matcher {
+	return &Matcher{load: load, index: NewIndex(nil)}
+}
+
+// Refresh loads offers and swaps the index, the previous index is kept on error
+func (m *Matcher) Refresh() error {
+	offers, err := m.load()
+	if err != nil {
+		return err
+	}
+	index := NewIndex(offers)
+
+	m.lock.Lock()
+	m.index = index
+	m.lock.Unlock()
+	return nil
+}
+
+// Index returns the current index
+func (m *Matcher) Index() *Index {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.index
+}
+
+func (m *Matcher) Match(r Request, limit int) []model.Offer {
+	return m.Index().Match(r, limit)
+}
+
+func FromContext(ctx context.Context, key string) *Matcher {
+	return ctx.Value(key).(*Matcher)
+}
+
+// set returns normalized values, nil for an empty list
+func set(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	s := map[string]bool{}
+	for _, v := range values {
+		if v = normalize(v); v != "" {
+			s[v] = true
+		}
+	}
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
+
+func allows(values map[string]bool, value string) bool {
+	return value == "" || values == nil || values[normalize(value)]
+}
+
+func normalize(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
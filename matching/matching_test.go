@@ -0,0 +1,55 @@
+package matching
+
+import (
+	"testing"
+
+	"mobilda/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ids(offers []model.Offer) []uint64 {
+	list := []uint64{}
+	for _, offer := range offers {
+		list = append(list, offer.Id)
+	}
+	return list
+}
+
+func testOffers() []model.Offer {
+	return []model.Offer{
+		{Id: 1, AccountId: 1, Payout: 1, IsActive: true, Countries: []string{"US", "GB"}},
+		{Id: 2, AccountId: 1, Payout: 3, IsActive: true, Countries: []string{"us"}, AllowedDevices: []string{"iPhone"}},
+		{Id: 3, AccountId: 2, Payout: 2, IsActive: true},
+		{Id: 4, AccountId: 2, Payout: 5, IsActive: false},
+		{Id: 5, AccountId: 2, Payout: 4, IsActive: true, Countries: []string{"DE"}, Languages: []string{"de"}},
+		{Id: 6, AccountId: 1, Payout: 2.5, IsActive: true, Cities: []string{"London"}, MinOsVersion: []string{"Android 5.1", "iOS 10"}},
+	}
+}
+
+func TestIndex_Match(t *testing.T) {
+	idx := NewIndex(testOffers())
+	assert.Equal(t, 5, idx.Len())
+
+	assert.Equal(t, []uint64{5, 2, 6, 3, 1}, ids(idx.Match(Request{}, 0)))
+	assert.Equal(t, []uint64{5, 2}, ids(idx.Match(Request{}, 2)))
+	assert.Equal(t, []uint64{2, 6, 3, 1}, ids(idx.Match(Request{Country: "us"}, 0)))
+	assert.Equal(t, []uint64{6, 3, 1}, ids(idx.Match(Request{Country: "GB"}, 0)))
+	assert.Equal(t, []uint64{6, 3, 1}, ids(idx.Match(Request{Country: "United Kingdom", City: "LONDON"}, 0)))
+	assert.Equal(t, []uint64{6, 3, 1}, ids(idx.Match(Request{Country: "US", Device: "android"}, 0)))
+	assert.Equal(t, []uint64{5, 3}, ids(idx.Match(Request{Country: "DE", Language: "DE", City: "Berlin"}, 0)))
+	assert.Equal(t, []uint64{5, 3}, ids(idx.Match(Request{Country: "Germany", Language: "German", City: "Berlin"}, 0)))
+	assert.Equal(t, []uint64{2, 3, 1}, ids(idx.Match(Request{Country: "US", OsVersion: "4.4"}, 0)))
+	assert.Equal(t, []uint64{2, 6, 3, 1}, ids(idx.Match(Request{Country: "US", OsVersion: "5.1.0"}, 0)))
+	assert.Equal(t, []uint64{3}, ids(idx.Match(Request{Country: "DE", Accounts: []int{2}, Language: "en"}, 0)))
+}
+
+func TestMatcher_Refresh(t *testing.T) {
+	offers := []model.Offer{}
+	m := NewMatcher(func() ([]model.Offer, error) { return offers, nil })
+	assert.Len(t, m.Match(Request{}, 0), 0)
+
+	offers = testOffers()
+	assert.NoError(t, m.Refresh())
+	assert.Len(t, m.Match(Request{}, 0), 5)
+}
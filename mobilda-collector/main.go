@@ -4,20 +4,48 @@ import (
 	"flag"
 
 	"mobilda"
+	"mobilda/collectors"
 )
 
 var (
-	cd  = flag.String("config-dir", "./etc", "Path to config file dir")
-	env = flag.String("env", "prod", "Config file environment")
+	cd       = flag.String("config-dir", "./etc", "Path to config file dir")
+	env      = flag.String("env", "prod", "Config file environment")
+	run      = flag.String("run", "", "Run collector once and exit, e.g. offers-collector")
+	accounts = flag.String("accounts", "", "Comma separated account ids to run collector for, all accounts if empty")
+	exp      = flag.String("export", "", "Export offers and exit, format: csv, ndjson or parquet")
+	out      = flag.String("out", "-", "Export output file, - for stdout")
+	filter   = flag.String("filter", "", "Export offers filter, same as GET /offers query, e.g. active=true&country=US")
 )
 
 func main() {
 	flag.Parse()
+
+	accountIds, err := collectors.ParseAccounts(*accounts)
+	if err != nil {
+		panic(err)
+	}
+
 	app, err := mobilda.NewApplication(*cd, *env)
 	if err != nil {
 		panic(err)
 	}
 
+	//Export offers
+	if *exp != "" {
+		if err := app.ExportOffers(*exp, *out, *filter); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	//Run single collector
+	if *run != "" {
+		if err := app.RunCollector(*run, accountIds); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	//Run application
 	app.Run()
 }
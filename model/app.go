@@ -0,0 +1,56 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"mobilda/platform"
+)
+
+// AppStorePackage is the store of apps keyed by package name of offers without a store link
+const AppStorePackage = "package"
+
+// App is a canonical app offers of all accounts are linked to, keyed by store and store app id
+type App struct {
+	tableName  struct{}  `sql:"mobilda.app"`
+	Id         int64     `sql:",pk" json:"id"`
+	Store      string    `sql:",notnull" json:"store"`
+	StoreAppId string    `sql:",notnull" json:"store_app_id"`
+	Platform   string    `json:"platform"`
+	BundleId   string    `json:"bundle_id"`
+	Title      string    `json:"title"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Key returns the unique key of the app
+func (this App) Key() string {
+	return this.Store + ":" + this.StoreAppId
+}
+
+// AppOf returns the app of the offer store link, or of the lowercased package name
+// if the offer has no store link. Numeric package names are App Store ids and are keyed
+// like App Store links. It returns false if the offer has neither.
+func AppOf(offer Offer, now time.Time) (App, bool) {
+	app := App{
+		Store:      offer.Store,
+		StoreAppId: offer.StoreAppId,
+		Platform:   offer.StorePlatform,
+		BundleId:   offer.BundleId,
+		Title:      offer.Title,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if app.StoreAppId == "" {
+		if id, ok := platform.AppStoreId(offer.PackageName); ok {
+			app.Store = platform.StoreAppStore
+			app.StoreAppId = id
+			app.Platform = platform.IOS
+			return app, true
+		}
+		app.Store = AppStorePackage
+		app.StoreAppId = strings.ToLower(strings.TrimSpace(offer.PackageName))
+		app.BundleId = strings.TrimSpace(offer.PackageName)
+	}
+	return app, app.StoreAppId != ""
+}
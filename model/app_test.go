@@ -0,0 +1,39 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppOf(t *testing.T) {
+	now := time.Now()
+
+	offer := Offer{PackageName: "com.king.candy", PreviewUrl: "https://play.google.com/store/apps/details?id=com.king.candy"}
+	offer.NormalizeStore()
+	app, ok := AppOf(offer, now)
+	assert.True(t, ok)
+	assert.Equal(t, "google_play:com.king.candy", app.Key())
+	assert.Equal(t, "android", app.Platform)
+
+	app, ok = AppOf(Offer{PackageName: " Com.King.Candy "}, now)
+	assert.True(t, ok)
+	assert.Equal(t, "package:com.king.candy", app.Key())
+	assert.Equal(t, "Com.King.Candy", app.BundleId)
+
+	link := Offer{PackageName: "id553834731", PreviewUrl: "https://apps.apple.com/us/app/candy-crush-saga/id553834731"}
+	link.NormalizeStore()
+	app, ok = AppOf(link, now)
+	assert.True(t, ok)
+	assert.Equal(t, "app_store:553834731", app.Key())
+
+	app, ok = AppOf(Offer{PackageName: " 553834731 "}, now)
+	assert.True(t, ok)
+	assert.Equal(t, "app_store:553834731", app.Key())
+	assert.Equal(t, "ios", app.Platform)
+	assert.Equal(t, "", app.BundleId)
+
+	_, ok = AppOf(Offer{PreviewUrl: "https://example.com"}, now)
+	assert.False(t, ok)
+}
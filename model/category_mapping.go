@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// CategoryMapping translates an upstream offer category, stored lowercased, to an internal vertical
+type CategoryMapping struct {
+	tableName struct{}  `sql:"mobilda.category_mapping"`
+	Category  string    `sql:",pk" json:"category"`
+	Vertical  string    `sql:",notnull" json:"vertical"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
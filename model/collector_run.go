@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+const (
+	RunStatusRunning = "running"
+	RunStatusSuccess = "success"
+	RunStatusPartial = "partial"
+	RunStatusFailed  = "failed"
+	RunStatusHeld    = "held"
+
+	RunStatusCancelled = "cancelled"
+)
+
+// CollectorRun is a single collector run for a single account
+type CollectorRun struct {
+	tableName  struct{}  `sql:"mobilda.collector_run"`
+	Id         int64     `json:"id"`
+	RunId      string    `json:"run_id"`
+	Collector  string    `json:"collector"`
+	AccountId  int       `json:"account_id"`
+	Status     string    `json:"status"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Pages      int       `sql:",notnull" json:"pages"`
+	Seen       int       `sql:",notnull" json:"seen"`
+	Inserted   int       `sql:",notnull" json:"inserted"`
+	Updated    int       `sql:",notnull" json:"updated"`
+	Stopped    int       `sql:",notnull" json:"stopped"`
+	Rejected   int       `sql:",notnull" json:"rejected"`
+	Skipped    int       `sql:",notnull" json:"skipped"`
+	Held       int       `sql:",notnull" json:"held"`
+	Errors     int       `sql:",notnull" json:"errors"`
+	Error      string    `json:"error,omitempty"`
+}
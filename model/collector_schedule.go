@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// CollectorSchedule keeps collector schedule changed at runtime
+type CollectorSchedule struct {
+	tableName    struct{}  `sql:"mobilda.collector_schedule"`
+	Collector    string    `sql:",pk" json:"collector"`
+	TimeInterval string    `json:"time_interval"`
+	IsPaused     bool      `sql:",notnull" json:"is_paused"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
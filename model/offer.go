@@ -1,8 +1,16 @@
 package model
 
 import (
+	"encoding/hex"
+	"math"
 	"strconv"
+	"strings"
 	"time"
+
+	"mobilda/geo"
+	"mobilda/platform"
+
+	"github.com/cnf/structhash"
 )
 
 const (
@@ -10,45 +18,151 @@ const (
 	OfferStatusStopped = false
 )
 
+// OfferSearchVector is the expression of the offer search_vector column. Package name dots are
+// replaced, so its parts are matched as words.
+const OfferSearchVector = `setweight(to_tsvector('simple', coalesce(title, '')), 'A') || ` +
+	`setweight(to_tsvector('simple', replace(coalesce(package_name, ''), '.', ' ')), 'A') || ` +
+	`setweight(to_tsvector('simple', coalesce(developer, '')), 'B') || ` +
+	`setweight(to_tsvector('simple', coalesce(description, '')), 'C')`
+
 type Offer struct {
 	tableName        struct{} `sql:"mobilda.offer"`
-	Id               uint64   `sql:"offer_id,pk"`
-	AccountId        int      `sql:"account_id,pk"`
-	PackageName      string   `sql:",notnull"`
-	Title            string
-	Description      string
-	Domain           string `sql:",notnull"`
-	PreviewUrl       string `sql:",notnull"`
-	TrackingUrl      string
-	BusinessModel    string
-	Rate             string
-	Currency         string
-	Thumbnail        string
-	Countries        []string `pg:",array"`
-	Cities           []string `pg:",array"`
-	Categories       []string `pg:",array"`
-	Languages        []string `pg:",array"`
-	BlackListSources []string `pg:",array"`
-	MobileSupport    string
-	AllowedDevices   []string `pg:",array"`
-	MinOsVersion     []string `pg:",array"`
-	AppPrice         string
-	AppRating        string
-	ContentRating    string
-	Developer        string
-	DeveloperWebsite string
-	PromoVideo       string
-	CapEnable        string
-	CapAmount        string
-	CapCurrentAmount string
-	CapFrequency     string
-	CappingField     string
-	CappingTimeframe string
-	IsActive         bool      `sql:",notnull"`
-	StatusChangedAt  time.Time `hash:"-"`
-	Hash             string    `hash:"-"`
+	Id               uint64   `sql:"offer_id,pk" json:"offer_id"`
+	AccountId        int      `sql:"account_id,pk" json:"account_id"`
+	PackageName      string   `sql:",notnull" json:"package_name"`
+	Title            string   `json:"title"`
+	Description      string   `json:"description"`
+	Domain           string   `sql:",notnull" json:"domain"`
+	PreviewUrl       string   `sql:",notnull" json:"preview_url"`
+	TrackingUrl      string   `json:"tracking_url"`
+	BusinessModel    string   `json:"business_model"`
+	Rate             string   `json:"rate"`
+	Payout           float64  `hash:"-" json:"payout"`
+	Currency         string   `json:"currency"`
+	Thumbnail        string   `json:"thumbnail"`
+	Countries        []string `pg:",array" json:"countries"`
+	Cities           []string `pg:",array" json:"cities"`
+	Categories       []string `pg:",array" json:"categories"`
+	Languages        []string `pg:",array" json:"languages"`
+	BlackListSources []string `pg:",array" json:"black_list_sources"`
+	MobileSupport    string   `json:"mobile_support"`
+	AllowedDevices   []string `pg:",array" json:"allowed_devices"`
+	MinOsVersion     []string `pg:",array" json:"min_os_version"`
+	// normalized AllowedDevices and MinOsVersion, versions are [major, minor, patch]
+	Platforms         []string `pg:",array" json:"platforms"`
+	DeviceClasses     []string `pg:",array" json:"device_classes"`
+	MinAndroidVersion []int    `pg:",array" json:"min_android_version"`
+	MinIosVersion     []int    `pg:",array" json:"min_ios_version"`
+	UnparsedTargeting []string `pg:",array" json:"unparsed_targeting"`
+	UnknownGeo        []string `pg:",array" json:"unknown_geo"`
+	// internal verticals of Categories, categories without mapping are listed in UnmappedCategories
+	Verticals          []string `pg:",array" json:"verticals"`
+	UnmappedCategories []string `pg:",array" json:"unmapped_categories"`
+	// app of the PreviewUrl store link, PackageMismatch is set when PackageName contradicts it
+	Store           string `json:"store"`
+	StorePlatform   string `json:"store_platform"`
+	StoreAppId      string `json:"store_app_id"`
+	BundleId        string `json:"bundle_id"`
+	PackageMismatch bool   `sql:",notnull" json:"package_mismatch"`
+	// canonical app of the offer, linked when the offer is written
+	AppId            int64     `hash:"-" json:"app_id"`
+	AppPrice         string    `json:"app_price"`
+	AppRating        string    `json:"app_rating"`
+	ContentRating    string    `json:"content_rating"`
+	Developer        string    `json:"developer"`
+	DeveloperWebsite string    `json:"developer_website"`
+	PromoVideo       string    `json:"promo_video"`
+	CapEnable        string    `json:"cap_enable"`
+	CapAmount        string    `json:"cap_amount"`
+	CapCurrentAmount string    `json:"cap_current_amount"`
+	CapFrequency     string    `json:"cap_frequency"`
+	CappingField     string    `json:"capping_field"`
+	CappingTimeframe string    `json:"capping_timeframe"`
+	IsActive         bool      `sql:",notnull" json:"is_active"`
+	StatusChangedAt  time.Time `hash:"-" json:"status_changed_at"`
+	// fields above are effective values, with an offer override upstream values are kept in Upstream
+	// and fields changed by the override are listed in Overridden
+	Overridden []string       `pg:",array" json:"overridden"`
+	Upstream   *OfferUpstream `hash:"-" json:"upstream,omitempty"`
+	Hash       string         `hash:"-" json:"-"`
 }
 
 func (this Offer) CacheId() string {
 	return "moboffer:" + strconv.FormatUint(this.Id, 10) + "account" + strconv.Itoa(this.AccountId)
 }
+
+// ContentHash returns the hash loaded offers are compared with, fields tagged hash:"-" are not hashed
+func (this Offer) ContentHash() string {
+	return hex.EncodeToString(structhash.Sha1(this, 1))
+}
+
+// Normalize runs the ingest normalization of the offer targeting and store link
+func (this *Offer) Normalize() {
+	this.NormalizeGeo()
+	this.NormalizeTargeting()
+	this.NormalizeStore()
+}
+
+// NormalizeGeo replaces countries and languages with ISO codes and unifies city names.
+// Unknown countries and languages are kept and listed in UnknownGeo prefixed with the field name.
+func (this *Offer) NormalizeGeo() {
+	countries, unknownCountries := geo.Countries(this.Countries)
+	languages, unknownLanguages := geo.Languages(this.Languages)
+
+	this.UnknownGeo = nil
+	for _, v := range unknownCountries {
+		this.UnknownGeo = append(this.UnknownGeo, "countries:"+v)
+		countries = append(countries, v)
+	}
+	for _, v := range unknownLanguages {
+		this.UnknownGeo = append(this.UnknownGeo, "languages:"+v)
+		languages = append(languages, v)
+	}
+
+	this.Countries = countries
+	this.Languages = languages
+	this.Cities = geo.Cities(this.Cities)
+}
+
+// NormalizeTargeting sets platforms, device classes and minimum versions parsed from AllowedDevices
+// and MinOsVersion. Normalized fields are hashed, so offers are rewritten when parsing changes.
+func (this *Offer) NormalizeTargeting() {
+	t := platform.Parse(this.AllowedDevices, this.MinOsVersion)
+	this.Platforms = t.Platforms()
+	this.DeviceClasses = t.Classes()
+	this.MinAndroidVersion = t.MinVersions[platform.Android].Ints()
+	this.MinIosVersion = t.MinVersions[platform.IOS].Ints()
+	this.UnparsedTargeting = t.Unparsed
+}
+
+// NormalizeStore sets the store app parsed from PreviewUrl, or from Domain if PreviewUrl is not a store link
+func (this *Offer) NormalizeStore() {
+	app, ok := platform.ParseStoreUrl(this.PreviewUrl)
+	if !ok {
+		app, ok = platform.ParseStoreUrl(this.Domain)
+	}
+
+	this.PackageMismatch = ok && app.Resolve(this.PackageName)
+	this.Store = app.Store
+	this.StorePlatform = app.Platform
+	this.StoreAppId = app.AppId
+	this.BundleId = app.BundleId
+}
+
+// UpstreamPayout returns the offer with upstream payout and rate if they are overridden
+func (this Offer) UpstreamPayout() Offer {
+	if this.Upstream != nil {
+		this.Payout = this.Upstream.Payout
+		this.Rate = this.Upstream.Rate
+	}
+	return this
+}
+
+// ParsePayout returns numeric payout from offer rate, zero if rate is not a number
+func ParsePayout(rate string) float64 {
+	payout, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+	if err != nil || math.IsNaN(payout) || math.IsInf(payout, 0) {
+		return 0
+	}
+	return payout
+}
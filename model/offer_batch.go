@@ -0,0 +1,140 @@
+package model
+
+import "time"
+
+// OfferBatch is a set of offer changes of one account written together
+type OfferBatch struct {
+	Inserted []Offer
+	Updated  []Offer
+	Stopped  []Offer
+
+	// Events are change events of the batch set by the collector. The repository marks reactivated
+	// offers and assigns the change feed seq, so sinks following it in a fan-out get them too.
+	Events []OfferEvent
+
+	// Payouts are payout points of created offers and of updated offers whose payout changed,
+	// set by the repository which knows the previous state
+	Payouts []PayoutChange
+}
+
+func (b *OfferBatch) Size() int {
+	return len(b.Inserted) + len(b.Updated) + len(b.Stopped)
+}
+
+// Split returns a batch per offer
+func (b *OfferBatch) Split() []*OfferBatch {
+	batches := []*OfferBatch{}
+	for _, offer := range b.Inserted {
+		batches = append(batches, &OfferBatch{Inserted: []Offer{offer}})
+	}
+	for _, offer := range b.Updated {
+		batches = append(batches, &OfferBatch{Updated: []Offer{offer}})
+	}
+	for _, offer := range b.Stopped {
+		batches = append(batches, &OfferBatch{Stopped: []Offer{offer}})
+	}
+	return batches
+}
+
+// Chunks returns batches of at most size offers, inserted first, then updated and stopped
+func (b *OfferBatch) Chunks(size int) []*OfferBatch {
+	chunks := []*OfferBatch{}
+	chunk := &OfferBatch{}
+	add := func(list *[]Offer, offer Offer) {
+		*list = append(*list, offer)
+		if chunk.Size() >= size {
+			chunks = append(chunks, chunk)
+			chunk = &OfferBatch{}
+		}
+	}
+	for _, offer := range b.Inserted {
+		add(&chunk.Inserted, offer)
+	}
+	for _, offer := range b.Updated {
+		add(&chunk.Updated, offer)
+	}
+	for _, offer := range b.Stopped {
+		add(&chunk.Stopped, offer)
+	}
+	if chunk.Size() > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// Partition splits the batch into offers for which held returns false and offers for which it returns true
+func (b *OfferBatch) Partition(held func(offer Offer, changeType string) bool) (apply, hold *OfferBatch) {
+	apply, hold = &OfferBatch{}, &OfferBatch{}
+	split := func(offers []Offer, changeType string, applyList, holdList *[]Offer) {
+		for _, offer := range offers {
+			if held(offer, changeType) {
+				*holdList = append(*holdList, offer)
+			} else {
+				*applyList = append(*applyList, offer)
+			}
+		}
+	}
+	split(b.Inserted, OfferChangeCreated, &apply.Inserted, &hold.Inserted)
+	split(b.Updated, OfferChangeUpdated, &apply.Updated, &hold.Updated)
+	split(b.Stopped, OfferChangeStopped, &apply.Stopped, &hold.Stopped)
+	return apply, hold
+}
+
+// NewEvents sets change events of the batch
+func (b *OfferBatch) NewEvents(now time.Time) {
+	b.Events = []OfferEvent{}
+	for i := range b.Inserted {
+		b.Events = append(b.Events, newOfferEvent(&b.Inserted[i], OfferChangeCreated, now))
+	}
+	for i := range b.Updated {
+		b.Events = append(b.Events, newOfferEvent(&b.Updated[i], OfferChangeUpdated, now))
+	}
+	for i := range b.Stopped {
+		b.Events = append(b.Events, newOfferEvent(&b.Stopped[i], OfferChangeStopped, now))
+	}
+}
+
+// MarkReactivated changes updated events of the offers to reactivated
+func (b *OfferBatch) MarkReactivated(reactivated map[uint64]bool) {
+	for i := range b.Events {
+		if b.Events[i].ChangeType == OfferChangeUpdated && reactivated[b.Events[i].OfferId] {
+			b.Events[i].ChangeType = OfferChangeReactivated
+		}
+	}
+}
+
+func newOfferEvent(offer *Offer, changeType string, now time.Time) OfferEvent {
+	return OfferEvent{
+		OfferChange: NewOfferChange(*offer, changeType, now),
+		Offer:       offer,
+	}
+}
+
+// NewPayouts sets payout changes of the batch, previous are stored states of updated and stopped offers by id.
+// Stopped offers are checked as well, offers disabled by an override may change other fields.
+func (b *OfferBatch) NewPayouts(previous map[uint64]Offer, now time.Time) {
+	b.Payouts = []PayoutChange{}
+	for _, offer := range b.Inserted {
+		b.Payouts = append(b.Payouts, PayoutChange{Title: offer.Title, Current: PayoutOf(offer, now)})
+	}
+	for _, offer := range append(append([]Offer{}, b.Updated...), b.Stopped...) {
+		current := PayoutOf(offer, now)
+		stored, ok := previous[offer.Id]
+		if !ok {
+			continue
+		}
+		prev := PayoutOf(stored, time.Time{})
+		if current.Differs(prev) {
+			b.Payouts = append(b.Payouts, PayoutChange{Title: offer.Title, Previous: &prev, Current: current})
+		}
+	}
+}
+
+// PayoutPoints returns current payout points of the batch
+func (b *OfferBatch) PayoutPoints() []OfferPayout {
+	points := make([]OfferPayout, len(b.Payouts))
+	for i, change := range b.Payouts {
+		points[i] = change.Current
+	}
+	return points
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testBatch() *OfferBatch {
+	return &OfferBatch{
+		Inserted: []Offer{{Id: 1, AccountId: 1}},
+		Updated:  []Offer{{Id: 2, AccountId: 1}, {Id: 3, AccountId: 1}},
+		Stopped:  []Offer{{Id: 4, AccountId: 1}},
+	}
+}
+
+func TestOfferBatch_NewEvents(t *testing.T) {
+	b := testBatch()
+	b.NewEvents(time.Now())
+	b.MarkReactivated(map[uint64]bool{3: true, 4: true})
+
+	types := []string{}
+	for _, e := range b.Events {
+		types = append(types, e.ChangeType)
+	}
+	assert.Equal(t, []string{
+		OfferChangeCreated,
+		OfferChangeUpdated,
+		OfferChangeReactivated,
+		OfferChangeStopped,
+	}, types)
+	assert.Equal(t, uint64(3), b.Events[2].Offer.Id)
+}
+
+func TestOfferBatch_Split(t *testing.T) {
+	batches := testBatch().Split()
+	assert.Len(t, batches, 4)
+	for _, b := range batches {
+		assert.Equal(t, 1, b.Size())
+	}
+	assert.Equal(t, uint64(4), batches[3].Stopped[0].Id)
+}
+
+func TestOfferBatch_NewPayouts(t *testing.T) {
+	b := &OfferBatch{
+		Inserted: []Offer{{Id: 1, AccountId: 1, Payout: 1}},
+		Updated: []Offer{
+			{Id: 2, AccountId: 1, Payout: 1.5, Currency: "USD"},
+			{Id: 3, AccountId: 1, Payout: 2, Currency: "USD", Title: "changed title"},
+		},
+	}
+	b.NewPayouts(map[uint64]Offer{
+		2: {Id: 2, AccountId: 1, Payout: 2, Currency: "USD"},
+		3: {Id: 3, AccountId: 1, Payout: 2, Currency: "USD"},
+	}, time.Now())
+
+	assert.Len(t, b.Payouts, 2)
+	assert.Nil(t, b.Payouts[0].Previous)
+	assert.Equal(t, 2.0, b.Payouts[1].Previous.Payout)
+	assert.Equal(t, 1.5, b.Payouts[1].Current.Payout)
+	assert.Equal(t, []uint64{1, 2}, []uint64{b.PayoutPoints()[0].OfferId, b.PayoutPoints()[1].OfferId})
+}
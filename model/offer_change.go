@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+const (
+	OfferChangeCreated     = "created"
+	OfferChangeUpdated     = "updated"
+	OfferChangeStopped     = "stopped"
+	OfferChangeReactivated = "reactivated"
+)
+
+// OfferChange is a record of the offers change feed, Seq grows in commit order
+type OfferChange struct {
+	tableName  struct{}  `sql:"mobilda.offer_change"`
+	Seq        int64     `sql:",pk" json:"seq"`
+	AccountId  int       `json:"account_id"`
+	OfferId    uint64    `json:"offer_id"`
+	ChangeType string    `json:"type"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+func NewOfferChange(offer Offer, changeType string, changedAt time.Time) OfferChange {
+	return OfferChange{
+		AccountId:  offer.AccountId,
+		OfferId:    offer.Id,
+		ChangeType: changeType,
+		ChangedAt:  changedAt,
+	}
+}
+
+// OfferEvent is a change feed record with the offer
+type OfferEvent struct {
+	OfferChange
+	Offer *Offer `json:"offer,omitempty"`
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+// Fields of the offer which can be overridden, listed in Offer.Overridden
+const (
+	OverrideTrackingUrl = "tracking_url"
+	OverridePayout      = "payout"
+	OverrideIsActive    = "is_active"
+)
+
+const (
+	OverrideActionSet    = "set"
+	OverrideActionDelete = "delete"
+)
+
+// OfferOverride is a local correction of an upstream offer merged over its upstream values on every sync.
+// Empty TrackingUrl and nil PayoutCap are not overridden, a disabled offer is stopped.
+type OfferOverride struct {
+	tableName   struct{}  `sql:"mobilda.offer_override"`
+	AccountId   int       `sql:",pk" json:"account_id"`
+	OfferId     uint64    `sql:",pk" json:"offer_id"`
+	TrackingUrl string    `json:"tracking_url,omitempty"`
+	PayoutCap   *float64  `json:"payout_cap,omitempty"`
+	Disabled    bool      `sql:",notnull" json:"disabled"`
+	Note        string    `json:"note,omitempty"`
+	UpdatedBy   string    `json:"updated_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// OfferOverrideAudit is a change of an offer override with the states before and after it,
+// Previous is nil for a new override and Override is nil for a deleted one
+type OfferOverrideAudit struct {
+	tableName struct{}       `sql:"mobilda.offer_override_audit"`
+	Id        int64          `sql:",pk" json:"id"`
+	AccountId int            `sql:",notnull" json:"account_id"`
+	OfferId   uint64         `sql:",notnull" json:"offer_id"`
+	Action    string         `sql:",notnull" json:"action"`
+	Previous  *OfferOverride `json:"previous"`
+	Override  *OfferOverride `json:"override"`
+	Author    string         `json:"author"`
+	Note      string         `json:"note"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// OfferUpstream are upstream values of the overridable offer fields
+type OfferUpstream struct {
+	TrackingUrl string  `json:"tracking_url"`
+	Rate        string  `json:"rate"`
+	Payout      float64 `json:"payout"`
+	IsActive    bool    `json:"is_active"`
+}
+
+// Empty reports whether the override changes nothing
+func (this OfferOverride) Empty() bool {
+	return this.TrackingUrl == "" && this.PayoutCap == nil && !this.Disabled
+}
+
+// Apply merges the override over the offer. Fields changed by the override are listed in offer.Overridden
+// and upstream values are kept in offer.Upstream if any field is changed.
+func (this OfferOverride) Apply(offer *Offer) {
+	upstream := &OfferUpstream{
+		TrackingUrl: offer.TrackingUrl,
+		Rate:        offer.Rate,
+		Payout:      offer.Payout,
+		IsActive:    offer.IsActive,
+	}
+	offer.Upstream = nil
+	offer.Overridden = nil
+
+	if this.TrackingUrl != "" && this.TrackingUrl != offer.TrackingUrl {
+		offer.TrackingUrl = this.TrackingUrl
+		offer.Overridden = append(offer.Overridden, OverrideTrackingUrl)
+	}
+	if this.PayoutCap != nil && offer.Payout > *this.PayoutCap {
+		offer.Payout = *this.PayoutCap
+		offer.Rate = strconv.FormatFloat(*this.PayoutCap, 'f', -1, 64)
+		offer.Overridden = append(offer.Overridden, OverridePayout)
+	}
+	if this.Disabled && offer.IsActive == OfferStatusActive {
+		offer.IsActive = OfferStatusStopped
+		offer.Overridden = append(offer.Overridden, OverrideIsActive)
+	}
+	if len(offer.Overridden) > 0 {
+		offer.Upstream = upstream
+	}
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOfferOverride_Apply(t *testing.T) {
+	limit := 1.0
+	override := OfferOverride{TrackingUrl: "http://local.track/1", PayoutCap: &limit, Disabled: true}
+	offer := Offer{TrackingUrl: "http://upstream.track/1", Rate: "1.5", Payout: 1.5, IsActive: true}
+
+	override.Apply(&offer)
+	assert.Equal(t, "http://local.track/1", offer.TrackingUrl)
+	assert.Equal(t, "1", offer.Rate)
+	assert.Equal(t, 1.0, offer.Payout)
+	assert.Equal(t, OfferStatusStopped, offer.IsActive)
+	assert.Equal(t, []string{OverrideTrackingUrl, OverridePayout, OverrideIsActive}, offer.Overridden)
+	assert.Equal(t, &OfferUpstream{TrackingUrl: "http://upstream.track/1", Rate: "1.5", Payout: 1.5, IsActive: true}, offer.Upstream)
+
+	// payout series keeps upstream payouts
+	assert.Equal(t, 1.5, PayoutOf(offer, offer.StatusChangedAt).Payout)
+
+	// payouts below the cap are kept, upstream is kept only with overridden fields
+	offer = Offer{Rate: "0.5", Payout: 0.5, IsActive: true}
+	OfferOverride{PayoutCap: &limit}.Apply(&offer)
+	assert.Equal(t, 0.5, offer.Payout)
+	assert.Nil(t, offer.Overridden)
+	assert.Nil(t, offer.Upstream)
+
+	assert.True(t, OfferOverride{}.Empty())
+	assert.False(t, OfferOverride{Disabled: true}.Empty())
+}
@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// OfferPayout is a point of the offer payout time series. It is recorded when the offer is created
+// and when its payout, currency or business model changes.
+type OfferPayout struct {
+	tableName     struct{}  `sql:"mobilda.offer_payout"`
+	Id            int64     `sql:",pk" json:"id"`
+	AccountId     int       `sql:",notnull" json:"account_id"`
+	OfferId       uint64    `sql:",notnull" json:"offer_id"`
+	Payout        float64   `sql:",notnull" json:"payout"`
+	Rate          string    `json:"rate"`
+	Currency      string    `json:"currency"`
+	BusinessModel string    `json:"business_model"`
+	RecordedAt    time.Time `json:"recorded_at"`
+}
+
+// PayoutChange is a new payout point of the batch with the stored state it replaces, nil for created offers.
+// RecordedAt of the previous point is not set.
+type PayoutChange struct {
+	Title    string
+	Previous *OfferPayout
+	Current  OfferPayout
+}
+
+// PayoutOf returns the payout point of the offer. The series follows upstream payouts, payout caps
+// of offer overrides are not recorded.
+func PayoutOf(offer Offer, now time.Time) OfferPayout {
+	offer = offer.UpstreamPayout()
+	return OfferPayout{
+		AccountId:     offer.AccountId,
+		OfferId:       offer.Id,
+		Payout:        offer.Payout,
+		Rate:          offer.Rate,
+		Currency:      offer.Currency,
+		BusinessModel: offer.BusinessModel,
+		RecordedAt:    now,
+	}
+}
+
+// Differs reports whether payout, currency or business model of the points differ
+func (this OfferPayout) Differs(p OfferPayout) bool {
+	return this.Payout != p.Payout || this.Currency != p.Currency || this.BusinessModel != p.BusinessModel
+}
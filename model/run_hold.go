@@ -0,0 +1,208 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Hold statuses, resolved is a hold with both approved and rejected changes
+const (
+	HoldStatusHeld       = "held"
+	HoldStatusSuperseded = "superseded"
+	HoldStatusApproved   = "approved"
+	HoldStatusRejected   = "rejected"
+	HoldStatusResolved   = "resolved"
+)
+
+const (
+	ChangeStatusPending  = "pending"
+	ChangeStatusApproved = "approved"
+	ChangeStatusRejected = "rejected"
+)
+
+const (
+	HoldActionHold    = "hold"
+	HoldActionPartial = "partial"
+)
+
+// RunAnomaly is a breached sanity check of an account run. Field is set for changed field checks.
+type RunAnomaly struct {
+	Check     string  `json:"check"`
+	Field     string  `json:"field,omitempty"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+}
+
+// RunHold is a set of offer changes of an account run held because of anomalies.
+// A newer hold of the account supersedes held ones, it reflects the current feed.
+type RunHold struct {
+	tableName  struct{}     `sql:"mobilda.run_hold"`
+	Id         int64        `sql:",pk" json:"id"`
+	RunId      string       `sql:",notnull" json:"run_id"`
+	AccountId  int          `sql:",notnull" json:"account_id"`
+	Action     string       `sql:",notnull" json:"action"`
+	Status     string       `sql:",notnull" json:"status"`
+	Anomalies  []RunAnomaly `json:"anomalies"`
+	Changes    int          `sql:",notnull" json:"changes"`
+	CreatedAt  time.Time    `json:"created_at"`
+	ResolvedAt *time.Time   `json:"resolved_at"`
+}
+
+// HeldChange is an offer change of a held run with the offer state it would write
+// and the state stored when the run was held, nil for created and reactivated offers.
+// Offers are stored as json without their hash, it is kept in Hash.
+type HeldChange struct {
+	tableName  struct{}    `sql:"mobilda.held_change"`
+	Id         int64       `sql:",pk" json:"id"`
+	HoldId     int64       `sql:",notnull" json:"hold_id"`
+	AccountId  int         `sql:",notnull" json:"account_id"`
+	OfferId    uint64      `sql:",notnull" json:"offer_id"`
+	ChangeType string      `sql:",notnull" json:"type"`
+	Status     string      `sql:",notnull" json:"status"`
+	Offer      Offer       `json:"offer"`
+	Hash       string      `json:"-"`
+	Previous   *Offer      `json:"previous,omitempty"`
+	Reason     string      `json:"reason,omitempty"`
+	ResolvedAt *time.Time  `json:"resolved_at"`
+	Diff       []FieldDiff `sql:"-" json:"diff,omitempty"`
+}
+
+// FieldDiff is a changed offer field by its json name
+type FieldDiff struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// diffSkip are offer fields set on every load or write, they are not compared
+var diffSkip = map[string]bool{"status_changed_at": true, "app_id": true}
+
+// OfferDiff returns fields of the offers which differ, ordered by name
+func OfferDiff(old, new Offer) []FieldDiff {
+	oldFields, newFields := offerFields(old), offerFields(new)
+	names := []string{}
+	for name := range newFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	diff := []FieldDiff{}
+	for _, name := range names {
+		if !diffSkip[name] && !reflect.DeepEqual(oldFields[name], newFields[name]) {
+			diff = append(diff, FieldDiff{Field: name, Old: oldFields[name], New: newFields[name]})
+		}
+	}
+	return diff
+}
+
+func offerFields(offer Offer) map[string]interface{} {
+	fields := map[string]interface{}{}
+	data, _ := json.Marshal(offer)
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+// SetDiff sets the diff of the held offer with the previous state
+func (this *HeldChange) SetDiff() {
+	if this.Previous != nil {
+		this.Diff = OfferDiff(*this.Previous, this.Offer)
+	}
+}
+
+// HoldStatus returns the status of a hold with all changes resolved
+func HoldStatus(changes []HeldChange) string {
+	approved, rejected := false, false
+	for _, change := range changes {
+		switch change.Status {
+		case ChangeStatusPending:
+			return HoldStatusHeld
+		case ChangeStatusApproved:
+			approved = true
+		case ChangeStatusRejected:
+			rejected = true
+		}
+	}
+	switch {
+	case approved && rejected:
+		return HoldStatusResolved
+	case rejected:
+		return HoldStatusRejected
+	default:
+		return HoldStatusApproved
+	}
+}
+
+// BatchOf returns the batch writing the held changes
+func BatchOf(changes []HeldChange) *OfferBatch {
+	b := &OfferBatch{}
+	for _, change := range changes {
+		offer := change.Offer
+		offer.Hash = change.Hash
+		switch change.ChangeType {
+		case OfferChangeCreated:
+			b.Inserted = append(b.Inserted, offer)
+		case OfferChangeStopped:
+			b.Stopped = append(b.Stopped, offer)
+		default:
+			b.Updated = append(b.Updated, offer)
+		}
+	}
+	return b
+}
+
+// ApprovedBatch returns the batch writing approved held changes over stored offers by id.
+// Changes stored by later runs are dropped, held inserts of stored offers are updated and held updates
+// of missing offers are inserted. A held stop changes only the status of the stored offer,
+// it is dropped if the offer is missing or already stopped.
+func ApprovedBatch(changes []HeldChange, stored map[uint64]Offer, now time.Time) *OfferBatch {
+	held := BatchOf(changes)
+	b := &OfferBatch{}
+	for _, offer := range append(append([]Offer{}, held.Inserted...), held.Updated...) {
+		current, ok := stored[offer.Id]
+		switch {
+		case !ok:
+			b.Inserted = append(b.Inserted, offer)
+		case current.Hash != offer.Hash:
+			b.Updated = append(b.Updated, offer)
+		}
+	}
+	for _, offer := range held.Stopped {
+		current, ok := stored[offer.Id]
+		if !ok || current.IsActive == OfferStatusStopped {
+			continue
+		}
+		current.IsActive = OfferStatusStopped
+		current.StatusChangedAt = now
+		current.Hash = current.ContentHash()
+		b.Stopped = append(b.Stopped, current)
+	}
+	return b
+}
+
+// HeldChanges returns pending changes of the batch with previous states of offers by id
+func (b *OfferBatch) HeldChanges(holdId int64, previous map[uint64]Offer) []HeldChange {
+	changes := []HeldChange{}
+	add := func(offers []Offer, changeType string) {
+		for _, offer := range offers {
+			change := HeldChange{
+				HoldId:     holdId,
+				AccountId:  offer.AccountId,
+				OfferId:    offer.Id,
+				ChangeType: changeType,
+				Status:     ChangeStatusPending,
+				Offer:      offer,
+				Hash:       offer.Hash,
+			}
+			if prev, ok := previous[offer.Id]; ok {
+				change.Previous = &prev
+			}
+			changes = append(changes, change)
+		}
+	}
+	add(b.Inserted, OfferChangeCreated)
+	add(b.Updated, OfferChangeUpdated)
+	add(b.Stopped, OfferChangeStopped)
+	return changes
+}
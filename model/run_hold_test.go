@@ -0,0 +1,86 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOfferDiff(t *testing.T) {
+	old := Offer{Id: 1, Title: "first", Countries: []string{"US"}, IsActive: true, AppId: 1}
+	new := Offer{Id: 1, Title: "first renamed", Countries: []string{"US", "GB"}, IsActive: true, AppId: 2}
+
+	assert.Equal(t, []FieldDiff{
+		{Field: "countries", Old: []interface{}{"US"}, New: []interface{}{"US", "GB"}},
+		{Field: "title", Old: "first", New: "first renamed"},
+	}, OfferDiff(old, new))
+	assert.Empty(t, OfferDiff(old, old))
+}
+
+func TestHoldStatus(t *testing.T) {
+	change := func(status string) HeldChange { return HeldChange{Status: status} }
+
+	assert.Equal(t, HoldStatusHeld, HoldStatus([]HeldChange{change(ChangeStatusApproved), change(ChangeStatusPending)}))
+	assert.Equal(t, HoldStatusApproved, HoldStatus([]HeldChange{change(ChangeStatusApproved)}))
+	assert.Equal(t, HoldStatusRejected, HoldStatus([]HeldChange{change(ChangeStatusRejected)}))
+	assert.Equal(t, HoldStatusResolved, HoldStatus([]HeldChange{change(ChangeStatusApproved), change(ChangeStatusRejected)}))
+}
+
+func TestOfferBatch_HeldChanges(t *testing.T) {
+	stored := Offer{Id: 2, Title: "second", IsActive: true}
+	b := &OfferBatch{
+		Inserted: []Offer{{Id: 1}},
+		Stopped:  []Offer{{Id: 2, Title: "second"}},
+	}
+
+	changes := b.HeldChanges(7, map[uint64]Offer{2: stored})
+	assert.Len(t, changes, 2)
+	assert.Nil(t, changes[0].Previous)
+	assert.Equal(t, &stored, changes[1].Previous)
+	assert.Equal(t, ChangeStatusPending, changes[1].Status)
+
+	changes[1].SetDiff()
+	assert.Equal(t, []FieldDiff{{Field: "is_active", Old: true, New: false}}, changes[1].Diff)
+
+	// held changes restore the batch
+	assert.Equal(t, b, BatchOf(changes))
+}
+
+func TestBatchOf_JSON(t *testing.T) {
+	b := &OfferBatch{Updated: []Offer{{Id: 1, Title: "first", IsActive: true, Hash: "hash"}}}
+
+	// held offers are stored as json, the hash is stored apart
+	changes := b.HeldChanges(7, nil)
+	data, err := json.Marshal(changes[0].Offer)
+	assert.NoError(t, err)
+	changes[0].Offer = Offer{}
+	assert.NoError(t, json.Unmarshal(data, &changes[0].Offer))
+	assert.Empty(t, changes[0].Offer.Hash)
+
+	assert.Equal(t, b, BatchOf(changes))
+}
+
+func TestApprovedBatch(t *testing.T) {
+	now := time.Now()
+	stored := map[uint64]Offer{
+		1: {Id: 1, Title: "first", IsActive: true, Hash: "first"},
+		2: {Id: 2, Title: "second", IsActive: true, Hash: "second"},
+		3: {Id: 3, Title: "third renamed", IsActive: true, Hash: "third renamed"},
+		4: {Id: 4, Title: "fourth"},
+	}
+	b := &OfferBatch{
+		Inserted: []Offer{{Id: 1, Title: "first", IsActive: true, Hash: "first"}, {Id: 5, Hash: "fifth"}},
+		Updated:  []Offer{{Id: 2, Title: "second renamed", IsActive: true, Hash: "second renamed"}},
+		Stopped:  []Offer{{Id: 3, Title: "third", Hash: "third"}, {Id: 4, Hash: "fourth"}, {Id: 6, Hash: "sixth"}},
+	}
+
+	// stored changes are dropped, stops keep fields stored after the run was held
+	approved := ApprovedBatch(b.HeldChanges(7, nil), stored, now)
+	assert.Equal(t, []Offer{{Id: 5, Hash: "fifth"}}, approved.Inserted)
+	assert.Equal(t, b.Updated, approved.Updated)
+	stopped := Offer{Id: 3, Title: "third renamed", StatusChangedAt: now}
+	stopped.Hash = stopped.ContentHash()
+	assert.Equal(t, []Offer{stopped}, approved.Stopped)
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusDead      = "dead"
+)
+
+// Webhook is a subscriber url receiving offer events matching its filters, empty filters match everything
+type Webhook struct {
+	tableName struct{}  `sql:"mobilda.webhook"`
+	Id        int       `json:"id"`
+	Url       string    `sql:",notnull" json:"url"`
+	Secret    string    `sql:",notnull" json:"secret,omitempty"`
+	Accounts  []int     `pg:",array" json:"accounts"`
+	Countries []string  `pg:",array" json:"countries"`
+	Types     []string  `pg:",array" json:"types"`
+	IsActive  bool      `sql:",notnull" json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDelivery is an outbox record of an offer event for a webhook.
+// Deliveries which run out of attempts are kept with the dead status.
+type WebhookDelivery struct {
+	tableName     struct{}   `sql:"mobilda.webhook_delivery"`
+	Id            int64      `json:"id"`
+	WebhookId     int        `json:"webhook_id"`
+	Seq           int64      `json:"seq"`
+	EventType     string     `json:"event_type"`
+	Payload       string     `json:"-"`
+	Status        string     `json:"status"`
+	Attempts      int        `sql:",notnull" json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     string     `json:"last_error"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+}
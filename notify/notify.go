@@ -0,0 +1,85 @@
+// Package notify publishes offer changes with postgres NOTIFY and lets other services listen to them
+package notify
+
+import (
+	"encoding/json"
+
+	"mobilda/model"
+
+	"gopkg.in/pg.v5"
+)
+
+// Channel is the postgres notification channel of offer changes
+const Channel = "mobilda_offer_changes"
+
+// Change is a compact notification payload, the offer is loaded by consumers when needed
+type Change struct {
+	AccountId int    `json:"account_id"`
+	OfferId   uint64 `json:"offer_id"`
+	Type      string `json:"type"`
+	Seq       int64  `json:"seq"`
+}
+
+func NewChange(c model.OfferChange) Change {
+	return Change{
+		AccountId: c.AccountId,
+		OfferId:   c.OfferId,
+		Type:      c.ChangeType,
+		Seq:       c.Seq,
+	}
+}
+
+// Decode parses a notification payload
+func Decode(payload string) (Change, error) {
+	c := Change{}
+	err := json.Unmarshal([]byte(payload), &c)
+	return c, err
+}
+
+// Publish sends a notification per event in a single statement.
+// Called in the transaction writing the events, notifications are delivered once it is committed.
+func Publish(tx *pg.Tx, events []model.OfferEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	payloads := make([]string, len(events))
+	for i, e := range events {
+		payload, err := json.Marshal(NewChange(e.OfferChange))
+		if err != nil {
+			return err
+		}
+		payloads[i] = string(payload)
+	}
+
+	_, err := tx.Exec("SELECT pg_notify(?, p) FROM unnest(?::text[]) p", Channel, pg.Array(payloads))
+	return err
+}
+
+// Listener receives offer change notifications
+type Listener struct {
+	ln *pg.Listener
+}
+
+// Listen subscribes to offer change notifications on a dedicated connection of the db
+func Listen(db *pg.DB) (*Listener, error) {
+	ln, err := db.Listen(Channel)
+	if err != nil {
+		return nil, err
+	}
+	return &Listener{ln: ln}, nil
+}
+
+// Receive waits for the next change. Notifications sent while the listener was disconnected are lost,
+// consumers catch up with the change feed from the last seen seq.
+func (l *Listener) Receive() (Change, error) {
+	_, payload, err := l.ln.Receive()
+	if err != nil {
+		return Change{}, err
+	}
+	return Decode(payload)
+}
+
+func (l *Listener) Close() error {
+	return l.ln.Close()
+}
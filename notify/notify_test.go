@@ -0,0 +1,25 @@
+package notify
+
+import (
+	"encoding/json"
+	"testing"
+
+	"mobilda/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChange_Payload(t *testing.T) {
+	change := NewChange(model.OfferChange{Seq: 42, AccountId: 1, OfferId: 1001, ChangeType: model.OfferChangeStopped})
+
+	payload, err := json.Marshal(change)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"account_id":1,"offer_id":1001,"type":"stopped","seq":42}`, string(payload))
+
+	decoded, err := Decode(string(payload))
+	assert.NoError(t, err)
+	assert.Equal(t, change, decoded)
+
+	_, err = Decode("{")
+	assert.Error(t, err)
+}
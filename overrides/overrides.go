@@ -0,0 +1,215 @@
+// Package overrides keeps local corrections of upstream offers which are merged over upstream data on every sync
+package overrides
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"mobilda/model"
+
+	"bitbucket.org/mobio/go-dbmanager"
+	"gopkg.in/pg.v5"
+)
+
+type key struct {
+	accountId int
+	offerId   uint64
+}
+
+// Overrides keeps offer overrides in memory, overrides are stored in offer_override table
+// and their changes in offer_override_audit table
+type Overrides struct {
+	db *dbmanager.DbManager
+
+	lock      sync.RWMutex
+	overrides map[key]model.OfferOverride
+}
+
+func New(db *dbmanager.DbManager) *Overrides {
+	return &Overrides{
+		db:        db,
+		overrides: map[key]model.OfferOverride{},
+	}
+}
+
+// Load reloads overrides from the database
+func (o *Overrides) Load() error {
+	list := []model.OfferOverride{}
+	if err := o.db.Model(&list).Select(); err != nil {
+		return err
+	}
+
+	o.Replace(list)
+	return nil
+}
+
+// Replace replaces overrides kept in memory
+func (o *Overrides) Replace(list []model.OfferOverride) {
+	overrides := make(map[key]model.OfferOverride, len(list))
+	for _, override := range list {
+		overrides[key{override.AccountId, override.OfferId}] = override
+	}
+
+	o.lock.Lock()
+	o.overrides = overrides
+	o.lock.Unlock()
+}
+
+// Apply merges the override of the offer over its upstream values, offers without override are reset
+func (o *Overrides) Apply(offer *model.Offer) {
+	o.lock.RLock()
+	override, ok := o.overrides[key{offer.AccountId, offer.Id}]
+	o.lock.RUnlock()
+
+	if !ok {
+		offer.Overridden = nil
+		offer.Upstream = nil
+		return
+	}
+	override.Apply(offer)
+}
+
+// List returns overrides ordered by account and offer, of the account if accountId is not zero
+func (o *Overrides) List(accountId int) ([]model.OfferOverride, error) {
+	list := []model.OfferOverride{}
+	query := o.db.Model(&list).Order("account_id", "offer_id")
+	if accountId != 0 {
+		query.Where("account_id = ?", accountId)
+	}
+	err := query.Select()
+	return list, err
+}
+
+// Get returns the override of the offer, it returns pg.ErrNoRows if the override does not exist
+func (o *Overrides) Get(accountId int, offerId uint64) (*model.OfferOverride, error) {
+	override := &model.OfferOverride{}
+	err := o.db.Model(override).
+		Where("account_id = ?", accountId).
+		Where("offer_id = ?", offerId).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return override, nil
+}
+
+// Set creates or replaces the override of the offer and records the change by author
+func (o *Overrides) Set(override model.OfferOverride, author string) (*model.OfferOverride, error) {
+	now := time.Now()
+	override.UpdatedBy = author
+	override.CreatedAt = now
+	override.UpdatedAt = now
+
+	err := o.db.RunInTransaction(func(tx *pg.Tx) error {
+		previous, err := o.locked(tx, override.AccountId, override.OfferId)
+		if err != nil {
+			return err
+		}
+		if previous != nil {
+			override.CreatedAt = previous.CreatedAt
+		}
+
+		_, err = tx.Model(&override).
+			OnConflict("(account_id, offer_id) DO UPDATE").
+			Set("tracking_url = EXCLUDED.tracking_url, payout_cap = EXCLUDED.payout_cap, disabled = EXCLUDED.disabled, " +
+				"note = EXCLUDED.note, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at").
+			Insert()
+		if err != nil {
+			return err
+		}
+
+		return o.audit(tx, model.OfferOverrideAudit{
+			AccountId: override.AccountId,
+			OfferId:   override.OfferId,
+			Action:    model.OverrideActionSet,
+			Previous:  previous,
+			Override:  &override,
+			Author:    author,
+			Note:      override.Note,
+			CreatedAt: now,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	o.lock.Lock()
+	o.overrides[key{override.AccountId, override.OfferId}] = override
+	o.lock.Unlock()
+	return &override, nil
+}
+
+// Delete removes the override of the offer and records the change by author,
+// it returns pg.ErrNoRows if the override does not exist
+func (o *Overrides) Delete(accountId int, offerId uint64, author, note string) error {
+	err := o.db.RunInTransaction(func(tx *pg.Tx) error {
+		previous, err := o.locked(tx, accountId, offerId)
+		if err != nil {
+			return err
+		}
+		if previous == nil {
+			return pg.ErrNoRows
+		}
+
+		_, err = tx.Model(&model.OfferOverride{}).
+			Where("account_id = ?", accountId).
+			Where("offer_id = ?", offerId).
+			Delete()
+		if err != nil {
+			return err
+		}
+
+		return o.audit(tx, model.OfferOverrideAudit{
+			AccountId: accountId,
+			OfferId:   offerId,
+			Action:    model.OverrideActionDelete,
+			Previous:  previous,
+			Author:    author,
+			Note:      note,
+			CreatedAt: time.Now(),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	o.lock.Lock()
+	delete(o.overrides, key{accountId, offerId})
+	o.lock.Unlock()
+	return nil
+}
+
+// Audit returns changes of the offer override, latest first
+func (o *Overrides) Audit(accountId int, offerId uint64) ([]model.OfferOverrideAudit, error) {
+	audit := []model.OfferOverrideAudit{}
+	err := o.db.Model(&audit).
+		Where("account_id = ?", accountId).
+		Where("offer_id = ?", offerId).
+		Order("id DESC").
+		Select()
+	return audit, err
+}
+
+// locked returns the stored override locked for update, nil if it does not exist
+func (o *Overrides) locked(tx *pg.Tx, accountId int, offerId uint64) (*model.OfferOverride, error) {
+	previous := &model.OfferOverride{}
+	_, err := tx.QueryOne(previous, `SELECT * FROM mobilda.offer_override WHERE account_id = ? AND offer_id = ? FOR UPDATE`,
+		accountId, offerId)
+	if err == pg.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return previous, nil
+}
+
+func (o *Overrides) audit(tx *pg.Tx, entry model.OfferOverrideAudit) error {
+	_, err := tx.Model(&entry).Insert()
+	return err
+}
+
+func FromContext(ctx context.Context, key string) *Overrides {
+	return ctx.Value(key).(*Overrides)
+}
@@ -0,0 +1,28 @@
+package overrides
+
+import (
+	"testing"
+
+	"mobilda/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	o := New(nil)
+	o.overrides = map[key]model.OfferOverride{
+		{1, 2}: {AccountId: 1, OfferId: 2, TrackingUrl: "http://local.track/2"},
+	}
+
+	offer := model.Offer{Id: 2, AccountId: 1, TrackingUrl: "http://upstream.track/2"}
+	o.Apply(&offer)
+	assert.Equal(t, "http://local.track/2", offer.TrackingUrl)
+	assert.Equal(t, "http://upstream.track/2", offer.Upstream.TrackingUrl)
+
+	// the same offer id of another account has no override
+	other := model.Offer{Id: 2, AccountId: 3, TrackingUrl: "http://upstream.track/2"}
+	o.Apply(&other)
+	assert.Equal(t, "http://upstream.track/2", other.TrackingUrl)
+	assert.Nil(t, other.Upstream)
+	assert.Nil(t, other.Overridden)
+}
@@ -0,0 +1,220 @@
+// Package platform parses offer device and OS version targeting into platforms, device classes and
+// minimum versions
+package platform
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	Android = "android"
+	IOS     = "ios"
+	Windows = "windows"
+)
+
+const (
+	Phone   = "phone"
+	Tablet  = "tablet"
+	Desktop = "desktop"
+)
+
+// Device is a platform and device class, empty fields mean any
+type Device struct {
+	Platform string `json:"platform"`
+	Class    string `json:"class"`
+}
+
+// Allows reports whether the device d of a traffic request is allowed by the targeted device
+func (t Device) Allows(d Device) bool {
+	return (t.Platform == "" || d.Platform == "" || t.Platform == d.Platform) &&
+		(t.Class == "" || d.Class == "" || t.Class == d.Class)
+}
+
+var platformWords = map[string]string{
+	"android": Android,
+	"ios":     IOS,
+	"iphone":  IOS,
+	"ipad":    IOS,
+	"ipod":    IOS,
+	"apple":   IOS,
+	"windows": Windows,
+	"wp":      Windows,
+}
+
+var classWords = map[string]string{
+	"iphone":     Phone,
+	"ipod":       Phone,
+	"phone":      Phone,
+	"smartphone": Phone,
+	"mobile":     Phone,
+	"ipad":       Tablet,
+	"tablet":     Tablet,
+	"desktop":    Desktop,
+	"pc":         Desktop,
+}
+
+// ParseDevice parses values like "Android", "iPad", "Android Tablet" or "Windows Phone"
+func ParseDevice(value string) (Device, bool) {
+	d := Device{}
+	words := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return r == ' ' || r == '_' || r == '-' || r == '/'
+	})
+	for _, w := range words {
+		p, isPlatform := platformWords[w]
+		c, isClass := classWords[w]
+		if !isPlatform && !isClass {
+			return Device{}, false
+		}
+		if isPlatform {
+			if d.Platform != "" && d.Platform != p {
+				return Device{}, false
+			}
+			d.Platform = p
+		}
+		if isClass {
+			d.Class = c
+		}
+	}
+	return d, len(words) > 0
+}
+
+// OsVersion is a minimum version of a platform, empty platform if the value does not name it
+type OsVersion struct {
+	Platform string
+	Version  Version
+}
+
+// ParseOsVersion parses values like "4.1", "Android 4.1" or "iOS 9.0 and up"
+func ParseOsVersion(value string) (OsVersion, bool) {
+	v, ok := ParseVersion(value)
+	if !ok {
+		return OsVersion{}, false
+	}
+	os := OsVersion{Version: v}
+	for _, w := range strings.Fields(strings.ToLower(value)) {
+		if p, ok := platformWords[w]; ok {
+			os.Platform = p
+			break
+		}
+	}
+	return os, true
+}
+
+// Targeting is the structured form of offer AllowedDevices and MinOsVersion
+type Targeting struct {
+	Devices []Device
+	// MinVersions are the lowest minimum versions by platform
+	MinVersions map[string]Version
+	// Unparsed are raw values which could not be parsed, prefixed with the field name
+	Unparsed []string
+}
+
+// Parse returns targeting of raw allowed devices and min OS versions. A version without a platform
+// applies to the single targeted platform, otherwise it is unparsed.
+func Parse(allowedDevices, minOsVersions []string) Targeting {
+	t := Targeting{MinVersions: map[string]Version{}}
+
+	platforms := map[string]bool{}
+	for _, value := range allowedDevices {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		d, ok := ParseDevice(value)
+		if !ok {
+			t.Unparsed = append(t.Unparsed, "allowed_devices:"+value)
+			continue
+		}
+		t.Devices = append(t.Devices, d)
+		if d.Platform != "" {
+			platforms[d.Platform] = true
+		}
+	}
+
+	for _, value := range minOsVersions {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		os, ok := ParseOsVersion(value)
+		if ok && os.Platform == "" && len(platforms) == 1 {
+			for p := range platforms {
+				os.Platform = p
+			}
+		}
+		if !ok || os.Platform == "" {
+			t.Unparsed = append(t.Unparsed, "min_os_version:"+value)
+			continue
+		}
+		if min, ok := t.MinVersions[os.Platform]; !ok || os.Version.Compare(min) < 0 {
+			t.MinVersions[os.Platform] = os.Version
+		}
+	}
+
+	return t
+}
+
+// Platforms returns sorted distinct platforms of devices and versions
+func (t Targeting) Platforms() []string {
+	found := map[string]bool{}
+	for _, d := range t.Devices {
+		if d.Platform != "" {
+			found[d.Platform] = true
+		}
+	}
+	for p := range t.MinVersions {
+		found[p] = true
+	}
+	return sorted(found)
+}
+
+// Classes returns sorted distinct device classes
+func (t Targeting) Classes() []string {
+	found := map[string]bool{}
+	for _, d := range t.Devices {
+		if d.Class != "" {
+			found[d.Class] = true
+		}
+	}
+	return sorted(found)
+}
+
+// Allows reports whether a traffic request of the device with the OS version is eligible.
+// Empty targeting allows everything, a request version is checked against its platform minimum,
+// or against the lowest minimum if the platform is unknown.
+func (t Targeting) Allows(d Device, osVersion Version) bool {
+	if len(t.Devices) > 0 {
+		allowed := false
+		for _, td := range t.Devices {
+			if td.Allows(d) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+
+	if osVersion == nil || len(t.MinVersions) == 0 {
+		return true
+	}
+	if d.Platform != "" {
+		min, ok := t.MinVersions[d.Platform]
+		return !ok || osVersion.Compare(min) >= 0
+	}
+	for _, min := range t.MinVersions {
+		if osVersion.Compare(min) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func sorted(set map[string]bool) []string {
+	list := []string{}
+	for v := range set {
+		list = append(list, v)
+	}
+	sort.Strings(list)
+	return list
+}
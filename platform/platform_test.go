@@ -0,0 +1,118 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVersion(t *testing.T) {
+	v, ok := ParseVersion("Android 4.1.2")
+	assert.True(t, ok)
+	assert.Equal(t, Version{4, 1, 2}, v)
+	assert.Equal(t, "4.1.2", v.String())
+	assert.Equal(t, []int{4, 1, 0}, Version{4, 1}.Ints())
+	assert.Nil(t, Version(nil).Ints())
+
+	_, ok = ParseVersion("any")
+	assert.False(t, ok)
+
+	assert.Equal(t, 0, Version{4, 1}.Compare(Version{4, 1, 0}))
+	assert.Equal(t, -1, Version{4, 1}.Compare(Version{4, 10}))
+	assert.Equal(t, 1, Version{10}.Compare(Version{9, 9}))
+}
+
+func TestParseDevice(t *testing.T) {
+	cases := map[string]Device{
+		"Android":        {Platform: Android},
+		"iPhone":         {Platform: IOS, Class: Phone},
+		"iPad":           {Platform: IOS, Class: Tablet},
+		"Android Tablet": {Platform: Android, Class: Tablet},
+		"windows_phone":  {Platform: Windows, Class: Phone},
+		"Mobile":         {Class: Phone},
+	}
+	for value, expected := range cases {
+		d, ok := ParseDevice(value)
+		assert.True(t, ok, value)
+		assert.Equal(t, expected, d, value)
+	}
+
+	for _, value := range []string{"", "Smart TV", "Android iPhone"} {
+		_, ok := ParseDevice(value)
+		assert.False(t, ok, value)
+	}
+}
+
+func TestParse(t *testing.T) {
+	targeting := Parse([]string{"Android", "iPad", "Fridge"}, []string{"Android 5.0", "4.4 and up", "iOS 9", "latest"})
+	assert.Equal(t, []string{Android, IOS}, targeting.Platforms())
+	assert.Equal(t, []string{Tablet}, targeting.Classes())
+	assert.Equal(t, Version{5, 0}, targeting.MinVersions[Android])
+	assert.Equal(t, Version{9}, targeting.MinVersions[IOS])
+	assert.Equal(t, []string{
+		"allowed_devices:Fridge",
+		"min_os_version:4.4 and up",
+		"min_os_version:latest",
+	}, targeting.Unparsed)
+
+	// a version without platform applies to the single targeted platform
+	targeting = Parse([]string{"Android"}, []string{"4.1", "5.0"})
+	assert.Equal(t, Version{4, 1}, targeting.MinVersions[Android])
+	assert.Empty(t, targeting.Unparsed)
+}
+
+func TestTargeting_Allows(t *testing.T) {
+	targeting := Parse([]string{"Android", "iPad"}, []string{"Android 8", "iOS 11"})
+
+	assert.True(t, targeting.Allows(Device{Platform: Android}, Version{8, 1}))
+	assert.False(t, targeting.Allows(Device{Platform: Android}, Version{7}))
+	assert.True(t, targeting.Allows(Device{Platform: IOS, Class: Tablet}, Version{11}))
+	assert.False(t, targeting.Allows(Device{Platform: IOS, Class: Phone}, nil))
+	assert.True(t, targeting.Allows(Device{}, Version{9}))
+	assert.False(t, targeting.Allows(Device{}, Version{7}))
+	assert.True(t, Parse(nil, nil).Allows(Device{Platform: Windows}, Version{1}))
+}
+
+func TestParseStoreUrl(t *testing.T) {
+	cases := map[string]StoreApp{
+		"https://play.google.com/store/apps/details?id=com.king.candy&hl=en": {StoreGooglePlay, Android, "com.king.candy", "com.king.candy"},
+		"market://details?id=com.king.candy":                                 {StoreGooglePlay, Android, "com.king.candy", "com.king.candy"},
+		"https://apps.apple.com/us/app/candy-crush/id553834731":              {StoreAppStore, IOS, "553834731", ""},
+		"itms-apps://itunes.apple.com/app/id553834731?mt=8":                  {StoreAppStore, IOS, "553834731", ""},
+		"https://www.amazon.com/gp/mas/dl/android?p=com.king.candy":          {StoreAmazon, Android, "com.king.candy", "com.king.candy"},
+		"https://galaxystore.samsung.com/detail/com.king.candy":              {StoreSamsung, Android, "com.king.candy", "com.king.candy"},
+		"https://appgallery.huawei.com/#/app/C100093547":                     {StoreHuawei, Android, "C100093547", ""},
+	}
+	for link, expected := range cases {
+		app, ok := ParseStoreUrl(link)
+		assert.True(t, ok, link)
+		assert.Equal(t, expected, app, link)
+	}
+
+	for _, link := range []string{"", "https://example.com/app", "https://play.google.com/store/apps/details", "://bad"} {
+		_, ok := ParseStoreUrl(link)
+		assert.False(t, ok, link)
+	}
+}
+
+func TestStoreAppResolve(t *testing.T) {
+	android := StoreApp{StoreGooglePlay, Android, "com.king.candy", "com.king.candy"}
+	assert.False(t, android.Resolve("com.King.Candy"))
+	assert.False(t, android.Resolve(""))
+	assert.True(t, android.Resolve("com.other.app"))
+
+	ios := StoreApp{Store: StoreAppStore, Platform: IOS, AppId: "553834731"}
+	assert.False(t, ios.Resolve("id553834731"))
+	assert.True(t, ios.Resolve("123"))
+	assert.False(t, ios.Resolve("com.midasplayer.apps.candycrushsaga"))
+	assert.Equal(t, "com.midasplayer.apps.candycrushsaga", ios.BundleId)
+}
+
+func TestAppStoreId(t *testing.T) {
+	id, ok := AppStoreId(" ID553834731 ")
+	assert.True(t, ok)
+	assert.Equal(t, "553834731", id)
+
+	_, ok = AppStoreId("com.king.candy")
+	assert.False(t, ok)
+}
@@ -0,0 +1,118 @@
+package platform
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	StoreGooglePlay = "google_play"
+	StoreAppStore   = "app_store"
+	StoreAmazon     = "amazon"
+	StoreSamsung    = "samsung"
+	StoreHuawei     = "huawei"
+)
+
+// StoreApp is an app of a store link. AppId is the store identifier the catalogue is joined by:
+// package name for Android stores, numeric id for the App Store, C-prefixed id for AppGallery.
+// BundleId is the package name or iOS bundle identifier when known.
+type StoreApp struct {
+	Store    string `json:"store"`
+	Platform string `json:"platform"`
+	AppId    string `json:"app_id"`
+	BundleId string `json:"bundle_id"`
+}
+
+var (
+	appStoreIdRe = regexp.MustCompile(`^id(\d+)$`)
+	numericIdRe  = regexp.MustCompile(`^(?:id)?(\d+)$`)
+	huaweiIdRe   = regexp.MustCompile(`^C\d+$`)
+	bundleIdRe   = regexp.MustCompile(`^[A-Za-z][\w-]*(\.[\w-]+)+$`)
+)
+
+// ParseStoreUrl parses Google Play, App Store, Amazon Appstore, Galaxy Store and AppGallery links,
+// including market://, itms-apps:// and amzn:// schemes. It returns false for other links.
+func ParseStoreUrl(value string) (StoreApp, bool) {
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return StoreApp{}, false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+
+	switch {
+	case scheme == "market" || host == "play.google.com" || host == "market.android.com":
+		return androidApp(StoreGooglePlay, u.Query().Get("id"))
+
+	case host == "apps.apple.com" || host == "itunes.apple.com":
+		for _, s := range segments {
+			if m := appStoreIdRe.FindStringSubmatch(s); m != nil {
+				return StoreApp{Store: StoreAppStore, Platform: IOS, AppId: m[1]}, true
+			}
+		}
+		if id := u.Query().Get("id"); numericIdRe.MatchString(id) {
+			return StoreApp{Store: StoreAppStore, Platform: IOS, AppId: strings.TrimPrefix(id, "id")}, true
+		}
+
+	case scheme == "amzn" || strings.HasPrefix(host, "amazon."):
+		return androidApp(StoreAmazon, u.Query().Get("p"))
+
+	case host == "galaxystore.samsung.com" || host == "apps.samsung.com":
+		if id := u.Query().Get("appId"); id != "" {
+			return androidApp(StoreSamsung, id)
+		}
+		if len(segments) > 0 {
+			return androidApp(StoreSamsung, segments[len(segments)-1])
+		}
+
+	case host == "appgallery.huawei.com" || host == "appgallery.cloud.huawei.com":
+		// ids are in the path or in the fragment of the single page app, e.g. /#/app/C100000000
+		segments = append(segments, strings.Split(u.Fragment, "/")...)
+		for _, s := range segments {
+			if huaweiIdRe.MatchString(s) {
+				return StoreApp{Store: StoreHuawei, Platform: Android, AppId: s}, true
+			}
+		}
+	}
+
+	return StoreApp{}, false
+}
+
+func androidApp(store, packageName string) (StoreApp, bool) {
+	packageName = strings.TrimSpace(packageName)
+	if !bundleIdRe.MatchString(packageName) {
+		return StoreApp{}, false
+	}
+	return StoreApp{Store: store, Platform: Android, AppId: packageName, BundleId: packageName}, true
+}
+
+// Resolve completes the app with the offer package name and reports whether they contradict.
+// Android package names must equal the link package, numeric iOS ids must equal the App Store id,
+// an iOS bundle identifier cannot be checked against the link and is taken as the bundle id.
+func (a *StoreApp) Resolve(packageName string) (mismatch bool) {
+	packageName = strings.TrimSpace(packageName)
+	if packageName == "" {
+		return false
+	}
+
+	switch {
+	case a.BundleId != "":
+		return !strings.EqualFold(a.BundleId, packageName)
+	case a.Store == StoreAppStore && numericIdRe.MatchString(strings.ToLower(packageName)):
+		return numericIdRe.FindStringSubmatch(strings.ToLower(packageName))[1] != a.AppId
+	case bundleIdRe.MatchString(packageName):
+		a.BundleId = packageName
+	}
+	return false
+}
+
+// AppStoreId returns the App Store id of a numeric package name like "284882215" or "id284882215"
+func AppStoreId(packageName string) (string, bool) {
+	m := numericIdRe.FindStringSubmatch(strings.ToLower(strings.TrimSpace(packageName)))
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
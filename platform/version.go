@@ -0,0 +1,71 @@
+package platform
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var versionRe = regexp.MustCompile(`\d+(\.\d+)*`)
+
+// versionParts is the length of stored versions, so they compare as postgres int arrays
+const versionParts = 3
+
+// Version is a dotted numeric version, e.g. 4.1.2
+type Version []int
+
+// ParseVersion returns the first dotted number of the value, e.g. "Android 4.1" is 4.1
+func ParseVersion(value string) (Version, bool) {
+	found := versionRe.FindString(value)
+	if found == "" {
+		return nil, false
+	}
+	parts := strings.Split(found, ".")
+	v := make(Version, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
+
+// Compare returns -1, 0 or 1, missing parts are zeros: 4.1 equals 4.1.0
+func (v Version) Compare(o Version) int {
+	for i := 0; i < len(v) || i < len(o); i++ {
+		a, b := 0, 0
+		if i < len(v) {
+			a = v[i]
+		}
+		if i < len(o) {
+			b = o[i]
+		}
+		if a < b {
+			return -1
+		}
+		if a > b {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Ints returns major, minor and patch, nil for an empty version
+func (v Version) Ints() []int {
+	if len(v) == 0 {
+		return nil
+	}
+	ints := make([]int, versionParts)
+	copy(ints, v)
+	return ints
+}
+
+func (v Version) String() string {
+	parts := make([]string, len(v))
+	for i, n := range v {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,16 @@
+package query
+
+import (
+	"mobilda/model"
+
+	"bitbucket.org/mobio/go-dbmanager"
+)
+
+// ActiveOffers returns all active offers
+func ActiveOffers(db *dbmanager.DbManager) ([]model.Offer, error) {
+	offers := []model.Offer{}
+	err := db.Model(&offers).
+		Where("is_active = ?", model.OfferStatusActive).
+		Select()
+	return offers, err
+}
@@ -0,0 +1,136 @@
+package query
+
+import (
+	"strings"
+
+	"mobilda/model"
+
+	"bitbucket.org/mobio/go-dbmanager"
+	"gopkg.in/pg.v5"
+)
+
+// AppSummary is a canonical app with the number of linked offers and accounts
+type AppSummary struct {
+	model.App
+	Offers       int `json:"offers"`
+	ActiveOffers int `json:"active_offers"`
+	Accounts     int `json:"accounts"`
+}
+
+// AppFilter is a filter of apps query, empty fields are not filtered
+type AppFilter struct {
+	Stores      []string
+	StoreAppIds []string
+	Platforms   []string
+	// MultiAccount selects apps offered by more than one account
+	MultiAccount bool
+}
+
+// AppOffer is an offer of the app compared side by side with offers of other accounts.
+// Best marks the highest payout active offer of its currency.
+type AppOffer struct {
+	AccountId         int      `json:"account_id"`
+	OfferId           uint64   `json:"offer_id"`
+	Title             string   `json:"title"`
+	IsActive          bool     `json:"is_active"`
+	BusinessModel     string   `json:"business_model"`
+	Payout            float64  `json:"payout"`
+	Currency          string   `json:"currency"`
+	Countries         []string `json:"countries"`
+	Cities            []string `json:"cities"`
+	Languages         []string `json:"languages"`
+	Platforms         []string `json:"platforms"`
+	DeviceClasses     []string `json:"device_classes"`
+	MinAndroidVersion []int    `json:"min_android_version"`
+	MinIosVersion     []int    `json:"min_ios_version"`
+	CapEnable         string   `json:"cap_enable"`
+	CapAmount         string   `json:"cap_amount"`
+	CapCurrentAmount  string   `json:"cap_current_amount"`
+	CapFrequency      string   `json:"cap_frequency"`
+	CappingField      string   `json:"capping_field"`
+	CappingTimeframe  string   `json:"capping_timeframe"`
+	PackageMismatch   bool     `json:"package_mismatch"`
+	Best              bool     `json:"best"`
+}
+
+// Apps returns apps with offer counts ordered by id
+func Apps(db *dbmanager.DbManager, f AppFilter, limit, offset int) ([]AppSummary, error) {
+	where, params := []string{"TRUE"}, []interface{}{}
+	if len(f.Stores) > 0 {
+		where = append(where, "a.store = ANY(?)")
+		params = append(params, pg.Array(f.Stores))
+	}
+	if len(f.StoreAppIds) > 0 {
+		where = append(where, "a.store_app_id = ANY(?)")
+		params = append(params, pg.Array(f.StoreAppIds))
+	}
+	if len(f.Platforms) > 0 {
+		where = append(where, "a.platform = ANY(?)")
+		params = append(params, pg.Array(f.Platforms))
+	}
+	having := "TRUE"
+	if f.MultiAccount {
+		having = "count(DISTINCT o.account_id) > 1"
+	}
+
+	apps := []AppSummary{}
+	_, err := db.Query(&apps, `
+		SELECT a.id, a.store, a.store_app_id, a.platform, a.bundle_id, a.title, a.created_at, a.updated_at,
+			count(o.offer_id) AS offers,
+			count(o.offer_id) FILTER (WHERE o.is_active) AS active_offers,
+			count(DISTINCT o.account_id) AS accounts
+		FROM mobilda.app AS a
+		LEFT JOIN mobilda.offer AS o ON o.app_id = a.id
+		WHERE `+strings.Join(where, " AND ")+`
+		GROUP BY a.id
+		HAVING `+having+`
+		ORDER BY a.id
+		LIMIT ? OFFSET ?`, append(params, limit, offset)...)
+	return apps, err
+}
+
+// AppOffers returns offers of the app, active offers first by payout
+func AppOffers(db *dbmanager.DbManager, appId int64) ([]AppOffer, error) {
+	offers := []model.Offer{}
+	err := db.Model(&offers).
+		Where("app_id = ?", appId).
+		Order("is_active DESC", "payout DESC NULLS LAST", "account_id", "offer_id").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	best := map[string]bool{}
+	list := make([]AppOffer, len(offers))
+	for i, o := range offers {
+		list[i] = AppOffer{
+			AccountId:         o.AccountId,
+			OfferId:           o.Id,
+			Title:             o.Title,
+			IsActive:          o.IsActive,
+			BusinessModel:     o.BusinessModel,
+			Payout:            o.Payout,
+			Currency:          o.Currency,
+			Countries:         o.Countries,
+			Cities:            o.Cities,
+			Languages:         o.Languages,
+			Platforms:         o.Platforms,
+			DeviceClasses:     o.DeviceClasses,
+			MinAndroidVersion: o.MinAndroidVersion,
+			MinIosVersion:     o.MinIosVersion,
+			CapEnable:         o.CapEnable,
+			CapAmount:         o.CapAmount,
+			CapCurrentAmount:  o.CapCurrentAmount,
+			CapFrequency:      o.CapFrequency,
+			CappingField:      o.CappingField,
+			CappingTimeframe:  o.CappingTimeframe,
+			PackageMismatch:   o.PackageMismatch,
+		}
+		// offers are ordered by payout, the first active offer of a currency pays best
+		if o.IsActive && !best[o.Currency] {
+			best[o.Currency] = true
+			list[i].Best = true
+		}
+	}
+	return list, nil
+}
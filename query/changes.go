@@ -0,0 +1,57 @@
+package query
+
+import (
+	"mobilda/model"
+
+	"bitbucket.org/mobio/go-dbmanager"
+)
+
+// Changes returns change feed records with seq greater than since, in seq order,
+// with the current state of offers.
+// Empty accounts mean all accounts.
+func Changes(db *dbmanager.DbManager, since int64, limit int, accounts []int) ([]model.OfferEvent, error) {
+	records := []model.OfferChange{}
+	q := db.Model(&records).
+		Where("seq > ?", since).
+		Order("seq ASC").
+		Limit(limit)
+	if len(accounts) > 0 {
+		q.WhereIn("account_id IN (?)", ints2interfaces(accounts)...)
+	}
+	if err := q.Select(); err != nil {
+		return nil, err
+	}
+
+	changes := make([]model.OfferEvent, 0, len(records))
+	if len(records) == 0 {
+		return changes, nil
+	}
+
+	accountIds, offerIds := []interface{}{}, []interface{}{}
+	for _, r := range records {
+		accountIds = append(accountIds, r.AccountId)
+		offerIds = append(offerIds, r.OfferId)
+	}
+
+	offers := []model.Offer{}
+	err := db.Model(&offers).
+		WhereIn("account_id IN (?)", accountIds...).
+		WhereIn("offer_id IN (?)", offerIds...).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	byId := map[string]*model.Offer{}
+	for i := range offers {
+		byId[offers[i].CacheId()] = &offers[i]
+	}
+	for _, r := range records {
+		changes = append(changes, model.OfferEvent{
+			OfferChange: r,
+			Offer:       byId[model.Offer{Id: r.OfferId, AccountId: r.AccountId}.CacheId()],
+		})
+	}
+
+	return changes, nil
+}
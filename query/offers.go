@@ -0,0 +1,357 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"mobilda/geo"
+	"mobilda/model"
+	"mobilda/platform"
+
+	"gopkg.in/pg.v5"
+	"gopkg.in/pg.v5/orm"
+)
+
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// offerSorts maps sort names to sql expressions, every sort is completed by account_id and offer_id
+var offerSorts = map[string]string{
+	"offer_id":          "offer_id",
+	"payout":            "COALESCE(payout, 0)",
+	"status_changed_at": "status_changed_at",
+	"title":             "title",
+}
+
+// minVersionConditions maps minimum OS version params to sql conditions,
+// versions are stored as [major, minor, patch] arrays which compare as versions
+var minVersionConditions = map[string]string{
+	"min_android_gte": "min_android_version >= ?",
+	"min_android_lte": "min_android_version <= ?",
+	"min_ios_gte":     "min_ios_version >= ?",
+	"min_ios_lte":     "min_ios_version <= ?",
+}
+
+// minVersionParams are names of minVersionConditions in a fixed order, so errors and sql do not vary
+var minVersionParams = []string{"min_android_gte", "min_android_lte", "min_ios_gte", "min_ios_lte"}
+
+// OfferFilter is a filter of offers query
+type OfferFilter struct {
+	Accounts       []int
+	IsActive       *bool
+	Countries      []string
+	Languages      []string
+	Cities         []string
+	Categories     []string
+	Verticals      []string
+	Devices        []string
+	BusinessModels []string
+	Currencies     []string
+	PayoutMin      *float64
+	PayoutMax      *float64
+	Platforms      []string
+	DeviceClasses  []string
+	Stores         []string
+	StoreAppIds    []string
+	BundleIds      []string
+	// PackageMismatch selects offers whose package name contradicts the store link
+	PackageMismatch *bool
+	// Overridden selects offers with effective values changed by an offer override
+	Overridden *bool
+	// MinVersions are normalized minimum OS version bounds by param name, e.g. min_android_gte
+	MinVersions map[string]platform.Version
+
+	Sort   string
+	Desc   bool
+	Limit  int
+	Cursor *Cursor
+}
+
+// Cursor points to the last offer of the previous page
+type Cursor struct {
+	Value     string `json:"v"`
+	AccountId int    `json:"a"`
+	OfferId   uint64 `json:"o"`
+}
+
+// ParseOfferFilter parses filter from query params:
+// account, active, country, language, city, category, vertical, device, business_model, currency - comma separated lists,
+// payout_min, payout_max, platform, device_class - normalized lists,
+// store, store_app_id, bundle_id - store link lists, package_mismatch, overridden,
+// min_android_gte, min_android_lte, min_ios_gte, min_ios_lte - minimum OS version bounds, sort (offer_id, payout, status_changed_at, title, "-" prefix for descending),
+// limit and cursor
+func ParseOfferFilter(params url.Values) (*OfferFilter, error) {
+	f := &OfferFilter{
+		Countries:      geo.Codes(listParam(params, "country"), geo.Country),
+		Languages:      geo.Codes(listParam(params, "language"), geo.Language),
+		Cities:         geo.Cities(listParam(params, "city")),
+		Categories:     listParam(params, "category"),
+		Verticals:      listParam(params, "vertical"),
+		Devices:        listParam(params, "device"),
+		BusinessModels: listParam(params, "business_model"),
+		Currencies:     listParam(params, "currency"),
+		Platforms:      listParam(params, "platform"),
+		DeviceClasses:  listParam(params, "device_class"),
+		Stores:         listParam(params, "store"),
+		StoreAppIds:    listParam(params, "store_app_id"),
+		BundleIds:      listParam(params, "bundle_id"),
+		MinVersions:    map[string]platform.Version{},
+		Sort:           "offer_id",
+		Limit:          DefaultLimit,
+	}
+
+	for _, v := range listParam(params, "account") {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid account %q", v)
+		}
+		f.Accounts = append(f.Accounts, id)
+	}
+
+	if v := params.Get("active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid active %q", v)
+		}
+		f.IsActive = &active
+	}
+
+	if v := params.Get("package_mismatch"); v != "" {
+		mismatch, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid package_mismatch %q", v)
+		}
+		f.PackageMismatch = &mismatch
+	}
+	if v := params.Get("overridden"); v != "" {
+		overridden, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid overridden %q", v)
+		}
+		f.Overridden = &overridden
+	}
+
+	var err error
+	if f.PayoutMin, err = floatParam(params, "payout_min"); err != nil {
+		return nil, err
+	}
+	if f.PayoutMax, err = floatParam(params, "payout_max"); err != nil {
+		return nil, err
+	}
+
+	for _, name := range minVersionParams {
+		if v := params.Get(name); v != "" {
+			version, ok := platform.ParseVersion(v)
+			if !ok {
+				return nil, fmt.Errorf("Invalid %s %q", name, v)
+			}
+			f.MinVersions[name] = version
+		}
+	}
+
+	if v := params.Get("sort"); v != "" {
+		f.Desc = strings.HasPrefix(v, "-")
+		f.Sort = strings.TrimPrefix(v, "-")
+		if _, ok := offerSorts[f.Sort]; !ok {
+			return nil, fmt.Errorf("Invalid sort %q", v)
+		}
+	}
+
+	if v := params.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit <= 0 {
+			return nil, fmt.Errorf("Invalid limit %q", v)
+		}
+		if f.Limit > MaxLimit {
+			f.Limit = MaxLimit
+		}
+	}
+
+	if v := params.Get("cursor"); v != "" {
+		if f.Cursor, err = DecodeCursor(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// Where applies filter conditions to the offers query
+func (f *OfferFilter) Where(q *orm.Query) *orm.Query {
+	if len(f.Accounts) > 0 {
+		q.WhereIn("account_id IN (?)", ints2interfaces(f.Accounts)...)
+	}
+	if f.IsActive != nil {
+		q.Where("is_active = ?", *f.IsActive)
+	}
+	if len(f.Countries) > 0 {
+		q.Where("countries && ?", pg.Array(f.Countries))
+	}
+	if len(f.Languages) > 0 {
+		q.Where("languages && ?", pg.Array(f.Languages))
+	}
+	if len(f.Cities) > 0 {
+		q.Where("cities && ?", pg.Array(f.Cities))
+	}
+	if len(f.Categories) > 0 {
+		q.Where("categories && ?", pg.Array(f.Categories))
+	}
+	if len(f.Verticals) > 0 {
+		q.Where("verticals && ?", pg.Array(f.Verticals))
+	}
+	if len(f.Devices) > 0 {
+		q.Where("allowed_devices && ?", pg.Array(f.Devices))
+	}
+	if len(f.BusinessModels) > 0 {
+		q.WhereIn("business_model IN (?)", strings2interfaces(f.BusinessModels)...)
+	}
+	if len(f.Currencies) > 0 {
+		q.WhereIn("currency IN (?)", strings2interfaces(f.Currencies)...)
+	}
+	if f.PayoutMin != nil {
+		q.Where("payout >= ?", *f.PayoutMin)
+	}
+	if f.PayoutMax != nil {
+		q.Where("payout <= ?", *f.PayoutMax)
+	}
+	if len(f.Platforms) > 0 {
+		q.Where("platforms && ?", pg.Array(f.Platforms))
+	}
+	if len(f.DeviceClasses) > 0 {
+		q.Where("device_classes && ?", pg.Array(f.DeviceClasses))
+	}
+	if len(f.Stores) > 0 {
+		q.WhereIn("store IN (?)", strings2interfaces(f.Stores)...)
+	}
+	if len(f.StoreAppIds) > 0 {
+		q.WhereIn("store_app_id IN (?)", strings2interfaces(f.StoreAppIds)...)
+	}
+	if len(f.BundleIds) > 0 {
+		q.WhereIn("bundle_id IN (?)", strings2interfaces(f.BundleIds)...)
+	}
+	if f.PackageMismatch != nil {
+		q.Where("package_mismatch = ?", *f.PackageMismatch)
+	}
+	if f.Overridden != nil {
+		q.Where("(coalesce(cardinality(overridden), 0) > 0) = ?", *f.Overridden)
+	}
+	for _, name := range minVersionParams {
+		if version, ok := f.MinVersions[name]; ok {
+			q.Where(minVersionConditions[name], pg.Array(version.Ints()))
+		}
+	}
+	return q
+}
+
+// Page applies filter conditions, sorting and cursor to the offers query.
+// It selects one extra offer to find out whether the next page exists.
+func (f *OfferFilter) Page(q *orm.Query) *orm.Query {
+	f.Where(q)
+
+	expr := offerSorts[f.Sort]
+	op, dir := ">", "ASC"
+	if f.Desc {
+		op, dir = "<", "DESC"
+	}
+
+	if f.Cursor != nil {
+		q.Where(fmt.Sprintf("(%s, account_id, offer_id) %s (?, ?, ?)", expr, op),
+			f.Cursor.Value, f.Cursor.AccountId, f.Cursor.OfferId)
+	}
+
+	return q.
+		Order(expr+" "+dir, "account_id "+dir, "offer_id "+dir).
+		Limit(f.Limit + 1)
+}
+
+// NextPage cuts the extra offer selected by Page and returns cursor of the next page,
+// empty cursor if it is the last page
+func (f *OfferFilter) NextPage(offers []model.Offer) ([]model.Offer, string) {
+	if len(offers) <= f.Limit {
+		return offers, ""
+	}
+
+	offers = offers[:f.Limit]
+	last := offers[len(offers)-1]
+
+	return offers, f.CursorOf(last).Encode()
+}
+
+// CursorOf returns cursor pointing to the offer
+func (f *OfferFilter) CursorOf(offer model.Offer) *Cursor {
+	c := &Cursor{AccountId: offer.AccountId, OfferId: offer.Id}
+	switch f.Sort {
+	case "payout":
+		c.Value = strconv.FormatFloat(offer.Payout, 'f', -1, 64)
+	case "status_changed_at":
+		c.Value = offer.StatusChangedAt.UTC().Format("2006-01-02 15:04:05.999999")
+	case "title":
+		c.Value = offer.Title
+	default:
+		c.Value = strconv.FormatUint(offer.Id, 10)
+	}
+	return c
+}
+
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid cursor")
+	}
+	c := &Cursor{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("Invalid cursor")
+	}
+	return c, nil
+}
+
+// listParam returns values of repeated or comma separated query param
+func listParam(params url.Values, name string) []string {
+	list := []string{}
+	for _, value := range params[name] {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				list = append(list, v)
+			}
+		}
+	}
+	return list
+}
+
+func floatParam(params url.Values, name string) (*float64, error) {
+	v := params.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s %q", name, v)
+	}
+	return &f, nil
+}
+
+func strings2interfaces(list []string) []interface{} {
+	values := make([]interface{}, len(list))
+	for i, v := range list {
+		values[i] = v
+	}
+	return values
+}
+
+func ints2interfaces(list []int) []interface{} {
+	values := make([]interface{}, len(list))
+	for i, v := range list {
+		values[i] = v
+	}
+	return values
+}
@@ -0,0 +1,76 @@
+package query
+
+import (
+	"net/url"
+	"testing"
+
+	"mobilda/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOfferFilter(t *testing.T) {
+	params, _ := url.ParseQuery("account=1,2&active=true&country=US&country=GB&payout_min=0.5&sort=-payout&limit=10")
+	f, err := ParseOfferFilter(params)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, f.Accounts)
+	assert.True(t, *f.IsActive)
+	assert.Equal(t, []string{"US", "GB"}, f.Countries)
+	assert.Equal(t, 0.5, *f.PayoutMin)
+	assert.Nil(t, f.PayoutMax)
+	assert.Equal(t, "payout", f.Sort)
+	assert.True(t, f.Desc)
+	assert.Equal(t, 10, f.Limit)
+
+	params, _ = url.ParseQuery("platform=android&min_android_gte=8&country=uk,Narnia&language=English")
+	f, err = ParseOfferFilter(params)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"GB", "Narnia"}, f.Countries)
+	assert.Equal(t, []string{"en"}, f.Languages)
+	assert.Equal(t, []string{"android"}, f.Platforms)
+	assert.Equal(t, []int{8, 0, 0}, f.MinVersions["min_android_gte"].Ints())
+
+	params, _ = url.ParseQuery("store=google_play&store_app_id=com.king.candy&package_mismatch=true&overridden=false")
+	f, err = ParseOfferFilter(params)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"google_play"}, f.Stores)
+	assert.Equal(t, []string{"com.king.candy"}, f.StoreAppIds)
+	assert.True(t, *f.PackageMismatch)
+	assert.False(t, *f.Overridden)
+
+	for _, q := range []string{"account=x", "active=maybe", "payout_max=high", "sort=rate", "limit=0", "cursor=abc!", "min_ios_lte=new", "package_mismatch=maybe", "overridden=maybe"} {
+		params, _ := url.ParseQuery(q)
+		_, err := ParseOfferFilter(params)
+		assert.NotNil(t, err, q)
+	}
+}
+
+func TestParseOfferFilter_MinVersionsOrder(t *testing.T) {
+	assert.Len(t, minVersionParams, len(minVersionConditions))
+
+	// the first invalid version param is reported on every call
+	params, _ := url.ParseQuery("min_ios_lte=new&min_android_gte=old")
+	for i := 0; i < 10; i++ {
+		_, err := ParseOfferFilter(params)
+		assert.EqualError(t, err, `Invalid min_android_gte "old"`)
+	}
+}
+
+func TestOfferFilter_NextPage(t *testing.T) {
+	f := &OfferFilter{Sort: "payout", Limit: 2}
+	offers := []model.Offer{
+		{Id: 1, AccountId: 1, Payout: 3},
+		{Id: 2, AccountId: 1, Payout: 2.5},
+		{Id: 3, AccountId: 2, Payout: 1},
+	}
+
+	page, next := f.NextPage(offers)
+	assert.Len(t, page, 2)
+	cursor, err := DecodeCursor(next)
+	assert.Nil(t, err)
+	assert.Equal(t, &Cursor{Value: "2.5", AccountId: 1, OfferId: 2}, cursor)
+
+	page, next = f.NextPage(offers[:2])
+	assert.Len(t, page, 2)
+	assert.Equal(t, "", next)
+}
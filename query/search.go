@@ -0,0 +1,96 @@
+package query
+
+import (
+	"mobilda/model"
+
+	"bitbucket.org/mobio/go-dbmanager"
+)
+
+// text search configuration of the offer search vector
+const searchConfig = "simple"
+
+// headline options, matched words are wrapped in <b></b>
+const (
+	titleHeadline       = "StartSel=<b>, StopSel=</b>, HighlightAll=true"
+	descriptionHeadline = "StartSel=<b>, StopSel=</b>, MaxFragments=2, MaxWords=20, MinWords=5"
+)
+
+// SearchResult is an offer matched by text search with its relevance and highlighted fragments
+type SearchResult struct {
+	model.Offer
+	Rank     float64 `json:"rank"`
+	Headline string  `json:"headline"`
+	Snippet  string  `json:"snippet"`
+}
+
+// searchHit is a ranked offer key selected by the search query
+type searchHit struct {
+	tableName struct{} `sql:"mobilda.offer"`
+	OfferId   uint64
+	AccountId int
+	Rank      float64
+	Headline  string
+	Snippet   string
+}
+
+// Search returns offers matching all words of the text ordered by relevance.
+// Title and package name weigh more than developer and description.
+func Search(db *dbmanager.DbManager, text string, filter *OfferFilter, limit, offset int) ([]SearchResult, error) {
+	hits := []searchHit{}
+	q := db.Model(&hits).
+		Column("offer_id", "account_id").
+		ColumnExpr("ts_rank(search_vector, plainto_tsquery(?, ?)) AS rank", searchConfig, text).
+		ColumnExpr("ts_headline(?, coalesce(title, ''), plainto_tsquery(?, ?), ?) AS headline",
+			searchConfig, searchConfig, text, titleHeadline).
+		ColumnExpr("ts_headline(?, coalesce(description, ''), plainto_tsquery(?, ?), ?) AS snippet",
+			searchConfig, searchConfig, text, descriptionHeadline).
+		Where("search_vector @@ plainto_tsquery(?, ?)", searchConfig, text).
+		OrderExpr("rank DESC").
+		Order("account_id", "offer_id").
+		Limit(limit).
+		Offset(offset)
+	filter.Where(q)
+
+	if err := q.Select(); err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(hits))
+	if len(hits) == 0 {
+		return results, nil
+	}
+
+	accountIds, offerIds := []interface{}{}, []interface{}{}
+	for _, hit := range hits {
+		accountIds = append(accountIds, hit.AccountId)
+		offerIds = append(offerIds, hit.OfferId)
+	}
+
+	offers := []model.Offer{}
+	err := db.Model(&offers).
+		WhereIn("account_id IN (?)", accountIds...).
+		WhereIn("offer_id IN (?)", offerIds...).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	byId := map[string]model.Offer{}
+	for _, offer := range offers {
+		byId[offer.CacheId()] = offer
+	}
+	for _, hit := range hits {
+		offer, ok := byId[model.Offer{Id: hit.OfferId, AccountId: hit.AccountId}.CacheId()]
+		if !ok {
+			continue
+		}
+		results = append(results, SearchResult{
+			Offer:    offer,
+			Rank:     hit.Rank,
+			Headline: hit.Headline,
+			Snippet:  hit.Snippet,
+		})
+	}
+
+	return results, nil
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"mobilda/consts"
+	"mobilda/errors"
+	"mobilda/model"
+	"mobilda/query"
+
+	"bitbucket.org/mobio/go-dbmanager"
+	"bitbucket.org/mobio/go-logger"
+	"github.com/pressly/chi"
+	"gopkg.in/pg.v5"
+)
+
+// Apps returns canonical apps with offer counts.
+// Params: store, store_app_id, platform - comma separated lists, multi_account=true, limit, offset
+func (ApiHandlers) Apps() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		db := dbmanager.FromContext(ctx, consts.DbManager_Component_Key)
+
+		limit, offset, err := queryLimit(r)
+		if err != nil {
+			http.Error(w, "Invalid limit or offset", 400)
+			return
+		}
+		filter := query.AppFilter{
+			Stores:       queryList(r, "store"),
+			StoreAppIds:  queryList(r, "store_app_id"),
+			Platforms:    queryList(r, "platform"),
+			MultiAccount: r.URL.Query().Get("multi_account") == "true",
+		}
+
+		apps, err := query.Apps(db, filter, limit, offset)
+		if err != nil {
+			logger.FromContext(ctx, consts.Logger_Component_Key).Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		renderJSON(w, 200, apps)
+	}
+}
+
+// App returns the app with offers of all accounts side by side, active offers first by payout
+func (ApiHandlers) App() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		db := dbmanager.FromContext(ctx, consts.DbManager_Component_Key)
+		log := logger.FromContext(ctx, consts.Logger_Component_Key)
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			http.Error(w, errors.ErrAppNotFound.Error(), 404)
+			return
+		}
+
+		app := model.App{}
+		err = db.Model(&app).Where("id = ?", id).Select()
+		if err == pg.ErrNoRows {
+			http.Error(w, errors.ErrAppNotFound.Error(), 404)
+			return
+		} else if err != nil {
+			log.Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		offers, err := query.AppOffers(db, app.Id)
+		if err != nil {
+			log.Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		renderJSON(w, 200, map[string]interface{}{
+			"app":    app,
+			"offers": offers,
+		})
+	}
+}
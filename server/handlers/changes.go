@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"mobilda/collectors"
+	"mobilda/consts"
+	"mobilda/query"
+
+	"bitbucket.org/mobio/go-dbmanager"
+	"bitbucket.org/mobio/go-logger"
+)
+
+// Changes returns offers created, updated and stopped after the cursor, in commit order.
+// Params: since (cursor, 0 for the beginning), account, limit.
+func (ApiHandlers) Changes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		db := dbmanager.FromContext(ctx, consts.DbManager_Component_Key)
+		log := logger.FromContext(ctx, consts.Logger_Component_Key)
+
+		since := int64(0)
+		if v := r.URL.Query().Get("since"); v != "" {
+			var err error
+			if since, err = strconv.ParseInt(v, 10, 64); err != nil || since < 0 {
+				http.Error(w, "Invalid since", 400)
+				return
+			}
+		}
+		accounts, err := collectors.ParseAccounts(r.URL.Query()["account"]...)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		limit, _, err := queryLimit(r)
+		if err != nil {
+			http.Error(w, "Invalid limit", 400)
+			return
+		}
+
+		changes, err := query.Changes(db, since, limit, accounts)
+		if err != nil {
+			log.Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		next := since
+		if len(changes) > 0 {
+			next = changes[len(changes)-1].Seq
+		}
+
+		renderJSON(w, 200, map[string]interface{}{
+			"changes":     changes,
+			"next_cursor": strconv.FormatInt(next, 10),
+		})
+	}
+}
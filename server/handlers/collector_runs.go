@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+
+	"mobilda/consts"
+	"mobilda/model"
+
+	"bitbucket.org/mobio/go-dbmanager"
+	"bitbucket.org/mobio/go-logger"
+)
+
+// CollectorRuns returns collector run history.
+// Filters: collector, account, status, run_id, from, to (RFC3339), limit, offset
+func (ApiHandlers) CollectorRuns() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		db := dbmanager.FromContext(ctx, consts.DbManager_Component_Key)
+		log := logger.FromContext(ctx, consts.Logger_Component_Key)
+		params := r.URL.Query()
+
+		account, err := queryInt(r, "account", 0)
+		if err != nil {
+			http.Error(w, "Invalid account", 400)
+			return
+		}
+		from, err := queryTime(r, "from")
+		if err != nil {
+			http.Error(w, "Invalid from, RFC3339 expected", 400)
+			return
+		}
+		to, err := queryTime(r, "to")
+		if err != nil {
+			http.Error(w, "Invalid to, RFC3339 expected", 400)
+			return
+		}
+		limit, offset, err := queryLimit(r)
+		if err != nil {
+			http.Error(w, "Invalid limit or offset", 400)
+			return
+		}
+
+		runs := []model.CollectorRun{}
+		query := db.Model(&runs).
+			Order("started_at DESC", "id DESC").
+			Limit(limit).
+			Offset(offset)
+
+		if v := params.Get("collector"); v != "" {
+			query.Where("collector = ?", v)
+		}
+		if v := params.Get("status"); v != "" {
+			query.Where("status = ?", v)
+		}
+		if v := params.Get("run_id"); v != "" {
+			query.Where("run_id = ?", v)
+		}
+		if account > 0 {
+			query.Where("account_id = ?", account)
+		}
+		if !from.IsZero() {
+			query.Where("started_at >= ?", from)
+		}
+		if !to.IsZero() {
+			query.Where("started_at < ?", to)
+		}
+
+		if err := query.Select(); err != nil {
+			log.Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		renderJSON(w, 200, runs)
+	}
+}
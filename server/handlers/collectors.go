@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"mobilda/collectors"
+	"mobilda/consts"
+
+	"github.com/pressly/chi"
+)
+
+// Collectors returns state of all registered collectors
+func (ApiHandlers) Collectors() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		registry := collectors.FromContext(r.Context(), consts.Collectors_Component_Key)
+		renderJSON(w, 200, registry.Statuses())
+	}
+}
+
+// Collector returns state of a single collector
+func (ApiHandlers) Collector() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		registry := collectors.FromContext(r.Context(), consts.Collectors_Component_Key)
+		status, ok := registry.Status(chi.URLParam(r, "collector"))
+		if !ok {
+			http.Error(w, "Collector not found", 404)
+			return
+		}
+		renderJSON(w, 200, status)
+	}
+}
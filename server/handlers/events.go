@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mobilda/collectors"
+	"mobilda/consts"
+	"mobilda/events"
+	"mobilda/geo"
+	"mobilda/model"
+	"mobilda/query"
+
+	"bitbucket.org/mobio/go-dbmanager"
+	"bitbucket.org/mobio/go-logger"
+)
+
+const (
+	eventsPingInterval = 15 * time.Second
+	eventsBacklogPage  = 500
+)
+
+// OfferEvents streams offer change events as server-sent events.
+// Params: account, country, type. Last-Event-ID header (or last_event_id param) resumes the stream
+// from the change feed, events are sent with their change seq as id.
+func (ApiHandlers) OfferEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		db := dbmanager.FromContext(ctx, consts.DbManager_Component_Key)
+		log := logger.FromContext(ctx, consts.Logger_Component_Key)
+		hub := events.FromContext(ctx, consts.Events_Component_Key)
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "Streaming is not supported", 500)
+			return
+		}
+
+		accounts, err := collectors.ParseAccounts(r.URL.Query()["account"]...)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		filter := events.Filter{
+			Accounts:  accounts,
+			Countries: geo.Codes(queryList(r, "country"), geo.Country),
+			Types:     queryList(r, "type"),
+		}
+
+		lastEventId := r.Header.Get("Last-Event-ID")
+		if lastEventId == "" {
+			lastEventId = r.URL.Query().Get("last_event_id")
+		}
+		last := int64(0)
+		if lastEventId != "" {
+			if last, err = strconv.ParseInt(lastEventId, 10, 64); err != nil || last < 0 {
+				http.Error(w, "Invalid Last-Event-ID", 400)
+				return
+			}
+		}
+
+		// subscribe before reading the backlog, so no event is missed in between
+		sub := hub.Subscribe(filter)
+		defer hub.Unsubscribe(sub)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(200)
+		flusher.Flush()
+
+		if lastEventId != "" {
+			for {
+				backlog, err := query.Changes(db, last, eventsBacklogPage, filter.Accounts)
+				if err != nil {
+					log.Error(err)
+					return
+				}
+				for _, e := range backlog {
+					if filter.Match(e) {
+						if err := writeEvent(w, e); err != nil {
+							return
+						}
+					}
+					last = e.Seq
+				}
+				flusher.Flush()
+				if len(backlog) < eventsBacklogPage {
+					break
+				}
+			}
+		}
+
+		var closed <-chan bool
+		if cn, ok := w.(http.CloseNotifier); ok {
+			closed = cn.CloseNotify()
+		}
+		ping := time.NewTicker(eventsPingInterval)
+		defer ping.Stop()
+
+		for {
+			select {
+			case e, ok := <-sub.C:
+				if !ok {
+					// the client fell behind, it reconnects with Last-Event-ID
+					return
+				}
+				if e.Seq <= last {
+					continue
+				}
+				if err := writeEvent(w, e); err != nil {
+					return
+				}
+				last = e.Seq
+				flusher.Flush()
+			case <-ping.C:
+				if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-closed:
+				return
+			}
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, e model.OfferEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.ChangeType, data)
+	return err
+}
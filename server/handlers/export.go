@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"mobilda/consts"
+	"mobilda/export"
+	"mobilda/query"
+
+	"bitbucket.org/mobio/go-dbmanager"
+	"bitbucket.org/mobio/go-logger"
+)
+
+// flushWriter flushes every write to the client, so the export is streamed
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (fw flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if f, ok := fw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return n, err
+}
+
+// ExportOffers streams offers matched by the filter as csv, ndjson or parquet.
+// Params: format and the filter params of Offers, except limit and cursor.
+func (ApiHandlers) ExportOffers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		db := dbmanager.FromContext(ctx, consts.DbManager_Component_Key)
+		log := logger.FromContext(ctx, consts.Logger_Component_Key)
+
+		filter, err := query.ParseOfferFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = export.FormatCSV
+		}
+		if err := export.CheckFormat(format); err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+
+		// writers could write the format header when created, so attachment headers are set first
+		w.Header().Set("Content-Type", export.ContentType(format))
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=offers.%s", format))
+
+		ew, err := export.NewWriter(format, flushWriter{w})
+		if err != nil {
+			log.Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		total, err := export.Export(db, filter, ew)
+		if err != nil {
+			// headers are already sent, the client gets a truncated file
+			log.WithField("exported", total).Error(err)
+			return
+		}
+		log.WithField("format", format).Infof("Exported %d offers", total)
+	}
+}
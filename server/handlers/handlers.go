@@ -1,3 +1,75 @@
 package handlers
 
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
 type ApiHandlers struct{}
+
+func renderJSON(w http.ResponseWriter, status int, data interface{}) {
+	jsonData, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		http.Error(w, "Server error", 500)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(jsonData)
+}
+
+// queryInt returns int query param or def if param is empty
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	return strconv.Atoi(v)
+}
+
+// queryList returns values of repeated or comma separated query param
+func queryList(r *http.Request, name string) []string {
+	list := []string{}
+	for _, value := range r.URL.Query()[name] {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				list = append(list, v)
+			}
+		}
+	}
+	return list
+}
+
+// queryTime returns RFC3339 time query param or zero time if param is empty
+func queryTime(r *http.Request, name string) (time.Time, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// queryLimit returns limit and offset query params
+func queryLimit(r *http.Request) (limit, offset int, err error) {
+	if limit, err = queryInt(r, "limit", defaultListLimit); err != nil {
+		return
+	}
+	if offset, err = queryInt(r, "offset", 0); err != nil {
+		return
+	}
+	if limit <= 0 || limit > maxListLimit {
+		limit = maxListLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return
+}
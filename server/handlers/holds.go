@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"mobilda/collectors"
+	"mobilda/consts"
+	"mobilda/errors"
+	"mobilda/model"
+
+	"bitbucket.org/mobio/go-dbmanager"
+	"bitbucket.org/mobio/go-logger"
+	"github.com/pressly/chi"
+	"gopkg.in/pg.v5"
+)
+
+// holdBody is an approve or reject request, empty offers resolve all pending changes
+type holdBody struct {
+	Offers []uint64 `json:"offers"`
+	Reason string   `json:"reason"`
+}
+
+// Holds returns runs held because of anomalies, latest first. Params: status, account, limit, offset
+func (ApiHandlers) Holds() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		db := dbmanager.FromContext(ctx, consts.DbManager_Component_Key)
+
+		limit, offset, err := queryLimit(r)
+		if err != nil {
+			http.Error(w, "Invalid limit or offset", 400)
+			return
+		}
+
+		holds := []model.RunHold{}
+		query := db.Model(&holds).
+			Order("id DESC").
+			Limit(limit).
+			Offset(offset)
+		if v := r.URL.Query().Get("status"); v != "" {
+			query.Where("status = ?", v)
+		}
+		if v := r.URL.Query().Get("account"); v != "" {
+			accountId, err := strconv.Atoi(v)
+			if err != nil {
+				http.Error(w, "Invalid account", 400)
+				return
+			}
+			query.Where("account_id = ?", accountId)
+		}
+
+		if err := query.Select(); err != nil {
+			logger.FromContext(ctx, consts.Logger_Component_Key).Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		renderJSON(w, 200, holds)
+	}
+}
+
+// Hold returns the held run with its changes and their diffs with offers stored when the run was held.
+// Param: status of changes
+func (ApiHandlers) Hold() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		db := dbmanager.FromContext(ctx, consts.DbManager_Component_Key)
+		log := logger.FromContext(ctx, consts.Logger_Component_Key)
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			http.Error(w, errors.ErrHoldNotFound.Error(), 404)
+			return
+		}
+
+		hold := model.RunHold{}
+		err = db.Model(&hold).Where("id = ?", id).Select()
+		if err == pg.ErrNoRows {
+			http.Error(w, errors.ErrHoldNotFound.Error(), 404)
+			return
+		} else if err != nil {
+			log.Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		changes := []model.HeldChange{}
+		query := db.Model(&changes).Where("hold_id = ?", hold.Id).Order("id")
+		if v := r.URL.Query().Get("status"); v != "" {
+			query.Where("status = ?", v)
+		}
+		if err := query.Select(); err != nil {
+			log.Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+		for i := range changes {
+			changes[i].SetDiff()
+		}
+
+		renderJSON(w, 200, map[string]interface{}{
+			"hold":    hold,
+			"changes": changes,
+		})
+	}
+}
+
+// ApproveHold applies pending changes of the held run atomically, body: {"offers": [offer ids]}.
+// Empty body or offers approve all pending changes.
+func (ApiHandlers) ApproveHold() http.HandlerFunc {
+	return resolveHold(true)
+}
+
+// RejectHold rejects pending changes of the held run, body: {"offers": [offer ids], "reason"}.
+// Empty offers reject all pending changes.
+func (ApiHandlers) RejectHold() http.HandlerFunc {
+	return resolveHold(false)
+}
+
+func resolveHold(approve bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			http.Error(w, errors.ErrHoldNotFound.Error(), 404)
+			return
+		}
+		body := holdBody{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && err != io.EOF {
+			http.Error(w, "Invalid request body", 400)
+			return
+		}
+
+		resolver, ok := collectors.FromContext(ctx, consts.Collectors_Component_Key).HoldResolver()
+		if !ok {
+			http.Error(w, errors.ErrCollectorNotFound.Error(), 404)
+			return
+		}
+
+		hold, err := resolver.ResolveHold(id, body.Offers, approve, body.Reason)
+		switch err {
+		case nil:
+			renderJSON(w, 200, hold)
+		case errors.ErrHoldNotFound, errors.ErrHeldChangeNotFound, errors.ErrAccountNotFound:
+			http.Error(w, err.Error(), 404)
+		case errors.ErrHoldResolved, errors.ErrCollectorIsRunning:
+			http.Error(w, err.Error(), 409)
+		default:
+			logger.FromContext(ctx, consts.Logger_Component_Key).Error(err)
+			http.Error(w, "Server error", 500)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"mobilda/collectors"
+	"mobilda/consts"
+
+	"github.com/pressly/chi"
+)
+
+// Job returns status and progress of the job
+func (ApiHandlers) Job() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		registry := collectors.FromContext(r.Context(), consts.Collectors_Component_Key)
+		job, ok := registry.Job(chi.URLParam(r, "id"))
+		if !ok {
+			http.Error(w, "Job not found", 404)
+			return
+		}
+		renderJSON(w, 200, job)
+	}
+}
+
+// CancelJob cancels the job, the collector stops it after the current offer
+func (ApiHandlers) CancelJob() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		registry := collectors.FromContext(r.Context(), consts.Collectors_Component_Key)
+		job, err := registry.CancelJob(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, err.Error(), 404)
+			return
+		}
+		renderJSON(w, 202, job)
+	}
+}
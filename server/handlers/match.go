@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+
+	"mobilda/collectors"
+	"mobilda/consts"
+	"mobilda/matching"
+	"mobilda/platform"
+)
+
+// MatchOffers returns active offers eligible for the traffic context ranked by payout.
+// Params: country, city, language, device, os_version, account, limit (0 - all)
+func (ApiHandlers) MatchOffers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		matcher := matching.FromContext(r.Context(), consts.Matcher_Component_Key)
+		params := r.URL.Query()
+
+		accounts, err := collectors.ParseAccounts(params["account"]...)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		limit, err := queryInt(r, "limit", defaultListLimit)
+		if err != nil || limit < 0 {
+			http.Error(w, "Invalid limit", 400)
+			return
+		}
+		if v := params.Get("device"); v != "" {
+			if _, ok := platform.ParseDevice(v); !ok {
+				http.Error(w, "Invalid device", 400)
+				return
+			}
+		}
+		if v := params.Get("os_version"); v != "" {
+			if _, ok := platform.ParseVersion(v); !ok {
+				http.Error(w, "Invalid os_version", 400)
+				return
+			}
+		}
+
+		index := matcher.Index()
+		offers := index.Match(matching.Request{
+			Country:   params.Get("country"),
+			City:      params.Get("city"),
+			Language:  params.Get("language"),
+			Device:    params.Get("device"),
+			OsVersion: params.Get("os_version"),
+			Accounts:  accounts,
+		}, limit)
+
+		renderJSON(w, 200, map[string]interface{}{
+			"offers":     offers,
+			"indexed_at": index.BuiltAt(),
+		})
+	}
+}
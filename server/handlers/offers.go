@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"mobilda/consts"
+	"mobilda/model"
+	"mobilda/query"
+
+	"bitbucket.org/mobio/go-dbmanager"
+	"bitbucket.org/mobio/go-logger"
+	"github.com/pressly/chi"
+	"gopkg.in/pg.v5"
+)
+
+// Offers returns filtered page of offers, see query.ParseOfferFilter for params
+func (ApiHandlers) Offers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		db := dbmanager.FromContext(ctx, consts.DbManager_Component_Key)
+		log := logger.FromContext(ctx, consts.Logger_Component_Key)
+
+		filter, err := query.ParseOfferFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+
+		offers := []model.Offer{}
+		if err := filter.Page(db.Model(&offers)).Select(); err != nil {
+			log.Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		offers, next := filter.NextPage(offers)
+		renderJSON(w, 200, map[string]interface{}{
+			"offers":      offers,
+			"next_cursor": next,
+		})
+	}
+}
+
+// Offer returns a single offer by account and offer id
+func (ApiHandlers) Offer() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		db := dbmanager.FromContext(ctx, consts.DbManager_Component_Key)
+		log := logger.FromContext(ctx, consts.Logger_Component_Key)
+
+		accountId, err := strconv.Atoi(chi.URLParam(r, "account"))
+		if err != nil {
+			http.Error(w, "Invalid account", 400)
+			return
+		}
+		offerId, err := strconv.ParseUint(chi.URLParam(r, "offer_id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid offer id", 400)
+			return
+		}
+
+		offer := model.Offer{}
+		err = db.Model(&offer).
+			Where("account_id = ?", accountId).
+			Where("offer_id = ?", offerId).
+			Select()
+		if err == pg.ErrNoRows {
+			http.Error(w, "Offer not found", 404)
+			return
+		} else if err != nil {
+			log.Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		renderJSON(w, 200, offer)
+	}
+}
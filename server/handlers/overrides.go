@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mobilda/consts"
+	"mobilda/errors"
+	"mobilda/model"
+	"mobilda/overrides"
+
+	"bitbucket.org/mobio/go-logger"
+	"github.com/pressly/chi"
+	"gopkg.in/pg.v5"
+)
+
+// overrideBody sets the offer override, author is recorded in the audit trail with the note
+type overrideBody struct {
+	TrackingUrl string   `json:"tracking_url"`
+	PayoutCap   *float64 `json:"payout_cap"`
+	Disabled    bool     `json:"disabled"`
+	Note        string   `json:"note"`
+	Author      string   `json:"author"`
+}
+
+// OfferOverrides returns offer overrides ordered by account and offer. Param: account
+func (ApiHandlers) OfferOverrides() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		accountId := 0
+		if v := r.URL.Query().Get("account"); v != "" {
+			var err error
+			if accountId, err = strconv.Atoi(v); err != nil {
+				http.Error(w, "Invalid account", 400)
+				return
+			}
+		}
+
+		list, err := overrides.FromContext(ctx, consts.Overrides_Component_Key).List(accountId)
+		if err != nil {
+			logger.FromContext(ctx, consts.Logger_Component_Key).Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		renderJSON(w, 200, list)
+	}
+}
+
+// OfferOverride returns the override of the offer
+func (ApiHandlers) OfferOverride() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		accountId, offerId, ok := overrideKey(w, r)
+		if !ok {
+			return
+		}
+
+		override, err := overrides.FromContext(ctx, consts.Overrides_Component_Key).Get(accountId, offerId)
+		if err == pg.ErrNoRows {
+			http.Error(w, errors.ErrOverrideNotFound.Error(), 404)
+			return
+		} else if err != nil {
+			logger.FromContext(ctx, consts.Logger_Component_Key).Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		renderJSON(w, 200, override)
+	}
+}
+
+// SetOfferOverride creates or replaces the override of the offer,
+// body: {"tracking_url", "payout_cap", "disabled", "note", "author"}.
+// The offer gets effective values on the next sync, upstream values are kept in its upstream field.
+func (ApiHandlers) SetOfferOverride() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		accountId, offerId, ok := overrideKey(w, r)
+		if !ok {
+			return
+		}
+
+		body := overrideBody{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", 400)
+			return
+		}
+		override := model.OfferOverride{
+			AccountId:   accountId,
+			OfferId:     offerId,
+			TrackingUrl: strings.TrimSpace(body.TrackingUrl),
+			PayoutCap:   body.PayoutCap,
+			Disabled:    body.Disabled,
+			Note:        body.Note,
+		}
+		author := strings.TrimSpace(body.Author)
+		switch {
+		case author == "":
+			http.Error(w, errors.ErrOverrideAuthorMissing.Error(), 400)
+			return
+		case override.Empty():
+			http.Error(w, errors.ErrOverrideEmpty.Error(), 400)
+			return
+		case override.PayoutCap != nil && *override.PayoutCap < 0:
+			http.Error(w, errors.ErrOverridePayoutCap.Error(), 400)
+			return
+		}
+
+		saved, err := overrides.FromContext(ctx, consts.Overrides_Component_Key).Set(override, author)
+		if err != nil {
+			logger.FromContext(ctx, consts.Logger_Component_Key).Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		renderJSON(w, 200, saved)
+	}
+}
+
+// DeleteOfferOverride removes the override of the offer. Params: author (required), note.
+// The offer gets upstream values on the next sync.
+func (ApiHandlers) DeleteOfferOverride() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		accountId, offerId, ok := overrideKey(w, r)
+		if !ok {
+			return
+		}
+		author := strings.TrimSpace(r.URL.Query().Get("author"))
+		if author == "" {
+			http.Error(w, errors.ErrOverrideAuthorMissing.Error(), 400)
+			return
+		}
+
+		err := overrides.FromContext(ctx, consts.Overrides_Component_Key).
+			Delete(accountId, offerId, author, r.URL.Query().Get("note"))
+		if err == pg.ErrNoRows {
+			http.Error(w, errors.ErrOverrideNotFound.Error(), 404)
+			return
+		} else if err != nil {
+			logger.FromContext(ctx, consts.Logger_Component_Key).Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		w.WriteHeader(204)
+	}
+}
+
+// OfferOverrideAudit returns changes of the offer override, latest first
+func (ApiHandlers) OfferOverrideAudit() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		accountId, offerId, ok := overrideKey(w, r)
+		if !ok {
+			return
+		}
+
+		audit, err := overrides.FromContext(ctx, consts.Overrides_Component_Key).Audit(accountId, offerId)
+		if err != nil {
+			logger.FromContext(ctx, consts.Logger_Component_Key).Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		renderJSON(w, 200, audit)
+	}
+}
+
+// overrideKey parses account and offer id url params, it writes the error if they are invalid
+func overrideKey(w http.ResponseWriter, r *http.Request) (int, uint64, bool) {
+	accountId, err := strconv.Atoi(chi.URLParam(r, "account"))
+	if err != nil {
+		http.Error(w, "Invalid account", 400)
+		return 0, 0, false
+	}
+	offerId, err := strconv.ParseUint(chi.URLParam(r, "offer_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid offer id", 400)
+		return 0, 0, false
+	}
+	return accountId, offerId, true
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"mobilda/consts"
+	"mobilda/model"
+
+	"bitbucket.org/mobio/go-dbmanager"
+	"bitbucket.org/mobio/go-logger"
+	"github.com/pressly/chi"
+)
+
+// OfferPayouts returns the payout time series of the offer, latest first. Params: since, limit, offset
+func (ApiHandlers) OfferPayouts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		db := dbmanager.FromContext(ctx, consts.DbManager_Component_Key)
+
+		accountId, err := strconv.Atoi(chi.URLParam(r, "account"))
+		if err != nil {
+			http.Error(w, "Invalid account", 400)
+			return
+		}
+		offerId, err := strconv.ParseUint(chi.URLParam(r, "offer_id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid offer id", 400)
+			return
+		}
+		since, err := queryTime(r, "since")
+		if err != nil {
+			http.Error(w, "Invalid since", 400)
+			return
+		}
+		limit, offset, err := queryLimit(r)
+		if err != nil {
+			http.Error(w, "Invalid limit or offset", 400)
+			return
+		}
+
+		payouts := []model.OfferPayout{}
+		query := db.Model(&payouts).
+			Where("account_id = ?", accountId).
+			Where("offer_id = ?", offerId).
+			Order("recorded_at DESC", "id DESC").
+			Limit(limit).
+			Offset(offset)
+		if !since.IsZero() {
+			query.Where("recorded_at >= ?", since)
+		}
+
+		if err := query.Select(); err != nil {
+			logger.FromContext(ctx, consts.Logger_Component_Key).Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		renderJSON(w, 200, payouts)
+	}
+}
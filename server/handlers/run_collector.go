@@ -1,30 +1,58 @@
 package handlers
 
 import (
-	"encoding/json"
 	"net/http"
 
+	"mobilda/collectors"
 	"mobilda/consts"
+	"mobilda/errors"
+	"mobilda/model"
 
-	"bitbucket.org/mobio/go-scheduler"
 	"github.com/pressly/chi"
 )
 
+// RunCollector starts collector as a background job and returns the job.
+// "all" starts every registered collector which is not running.
+// Query param account limits the run to selected accounts, e.g. ?account=2 or ?account=1,2
 func (ApiHandlers) RunCollector() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
-		shed := scheduler.FromContext(ctx, consts.Scheduler_Component_Key)
+		registry := collectors.FromContext(ctx, consts.Collectors_Component_Key)
 		collectorName := chi.URLParam(r, "collector")
+
+		accounts, err := collectors.ParseAccounts(r.URL.Query()["account"]...)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		if _, err := collectors.FindAccounts(ctx.Value(consts.Accounts_Key).([]*model.Account), accounts); err != nil {
+			http.Error(w, errors.ErrAccountNotFound.Error(), 400)
+			return
+		}
+
 		if collectorName == "all" {
-			shed.StartAll()
-		} else {
-			shed.StartCollector(collectorName)
+			jobs := []*collectors.Job{}
+			for _, name := range registry.Names() {
+				if job, err := registry.Run(name, accounts); err == nil {
+					jobs = append(jobs, job)
+				}
+			}
+			renderJSON(w, 202, jobs)
+			return
 		}
 
-		jsonData, err := json.MarshalIndent(map[string]string{"status": "ok"}, "", "  ")
-		if err != nil {
+		job, err := registry.Run(collectorName, accounts)
+		switch err {
+		case nil:
+			renderJSON(w, 202, job)
+		case errors.ErrCollectorNotFound:
+			http.Error(w, err.Error(), 404)
+		case errors.ErrCollectorIsRunning:
+			http.Error(w, err.Error(), 409)
+		case errors.ErrAccountsNotSupported:
+			http.Error(w, err.Error(), 400)
+		default:
 			http.Error(w, "Server error", 500)
 		}
-		w.Write(jsonData)
 	}
 }
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"mobilda/collectors"
+	"mobilda/consts"
+	"mobilda/errors"
+
+	"bitbucket.org/mobio/go-logger"
+	"github.com/pressly/chi"
+)
+
+// RescheduleCollector changes collector time interval, body: {"interval": "every 10 minutes"}.
+// Empty interval restores the config value.
+func (ApiHandlers) RescheduleCollector() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body := struct {
+			Interval string `json:"interval"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", 400)
+			return
+		}
+
+		registry := collectors.FromContext(r.Context(), consts.Collectors_Component_Key)
+		name := chi.URLParam(r, "collector")
+
+		var status collectors.Status
+		var err error
+		if body.Interval == "" {
+			status, err = registry.ResetInterval(name)
+		} else {
+			status, err = registry.Reschedule(name, body.Interval)
+		}
+		renderSchedule(w, r, status, err)
+	}
+}
+
+// PauseCollector pauses scheduled runs of the collector
+func (ApiHandlers) PauseCollector() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		registry := collectors.FromContext(r.Context(), consts.Collectors_Component_Key)
+		status, err := registry.Pause(chi.URLParam(r, "collector"))
+		renderSchedule(w, r, status, err)
+	}
+}
+
+// ResumeCollector resumes scheduled runs of the collector
+func (ApiHandlers) ResumeCollector() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		registry := collectors.FromContext(r.Context(), consts.Collectors_Component_Key)
+		status, err := registry.Resume(chi.URLParam(r, "collector"))
+		renderSchedule(w, r, status, err)
+	}
+}
+
+func renderSchedule(w http.ResponseWriter, r *http.Request, status collectors.Status, err error) {
+	switch err {
+	case nil:
+		renderJSON(w, 200, status)
+	case errors.ErrCollectorNotFound:
+		http.Error(w, err.Error(), 404)
+	case errors.ErrCollectorNotSchedulable:
+		http.Error(w, err.Error(), 400)
+	default:
+		if _, ok := err.(collectors.IntervalError); ok {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		logger.FromContext(r.Context(), consts.Logger_Component_Key).Error(err)
+		http.Error(w, "Server error", 500)
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"mobilda/consts"
+	"mobilda/query"
+
+	"bitbucket.org/mobio/go-dbmanager"
+	"bitbucket.org/mobio/go-logger"
+)
+
+// SearchOffers returns offers matching the q text ordered by relevance, with highlighted title
+// and description fragments. Offer filters of the offers list apply, paging by limit and offset.
+func (ApiHandlers) SearchOffers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		db := dbmanager.FromContext(ctx, consts.DbManager_Component_Key)
+		params := r.URL.Query()
+
+		text := strings.TrimSpace(params.Get("q"))
+		if text == "" {
+			http.Error(w, "Search text q is required", 400)
+			return
+		}
+		filter, err := query.ParseOfferFilter(params)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		limit, offset, err := queryLimit(r)
+		if err != nil {
+			http.Error(w, "Invalid limit or offset", 400)
+			return
+		}
+
+		results, err := query.Search(db, text, filter, limit, offset)
+		if err != nil {
+			logger.FromContext(ctx, consts.Logger_Component_Key).Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		renderJSON(w, 200, results)
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"mobilda/consts"
+
+	"bitbucket.org/mobio/go-dbmanager"
+	"bitbucket.org/mobio/go-logger"
+)
+
+// valueCount is a raw value which could not be normalized with the number of offers having it
+type valueCount struct {
+	Value  string `json:"value"`
+	Offers int    `json:"offers"`
+}
+
+// UnparsedTargeting returns raw allowed devices and min OS versions which could not be parsed.
+// Param: active
+func (ApiHandlers) UnparsedTargeting() http.HandlerFunc {
+	return renderValueCounts("unparsed_targeting")
+}
+
+// UnknownGeo returns countries and languages missing from the reference tables. Param: active
+func (ApiHandlers) UnknownGeo() http.HandlerFunc {
+	return renderValueCounts("unknown_geo")
+}
+
+// renderValueCounts renders distinct values of the offer array column by the number of offers
+func renderValueCounts(column string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		db := dbmanager.FromContext(ctx, consts.DbManager_Component_Key)
+
+		where := "TRUE"
+		if r.URL.Query().Get("active") == "true" {
+			where = "is_active"
+		}
+
+		values := []valueCount{}
+		_, err := db.Query(&values, `
+			SELECT value, count(*) AS offers
+			FROM mobilda.offer, unnest(`+column+`) AS value
+			WHERE `+where+`
+			GROUP BY value
+			ORDER BY offers DESC, value`)
+		if err != nil {
+			logger.FromContext(ctx, consts.Logger_Component_Key).Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		renderJSON(w, 200, values)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"mobilda/consts"
+	"mobilda/errors"
+	"mobilda/taxonomy"
+
+	"bitbucket.org/mobio/go-logger"
+	"github.com/pressly/chi"
+	"gopkg.in/pg.v5"
+)
+
+// CategoryMappings returns category mappings ordered by category
+func (ApiHandlers) CategoryMappings() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		mappings, err := taxonomy.FromContext(ctx, consts.Taxonomy_Component_Key).Mappings()
+		if err != nil {
+			logger.FromContext(ctx, consts.Logger_Component_Key).Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		renderJSON(w, 200, mappings)
+	}
+}
+
+// SetCategoryMapping maps the upstream category to a vertical, body: {"vertical"}.
+// Offers get the new verticals on the next sync.
+func (ApiHandlers) SetCategoryMapping() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		body := struct {
+			Vertical string `json:"vertical"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", 400)
+			return
+		}
+		vertical := strings.TrimSpace(body.Vertical)
+		if vertical == "" {
+			http.Error(w, errors.ErrMappingVerticalMissing.Error(), 400)
+			return
+		}
+
+		mapping, err := taxonomy.FromContext(ctx, consts.Taxonomy_Component_Key).
+			Set(chi.URLParam(r, "category"), vertical)
+		if err != nil {
+			logger.FromContext(ctx, consts.Logger_Component_Key).Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		renderJSON(w, 200, mapping)
+	}
+}
+
+// DeleteCategoryMapping removes the category mapping, the category becomes unmapped on the next sync
+func (ApiHandlers) DeleteCategoryMapping() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		err := taxonomy.FromContext(ctx, consts.Taxonomy_Component_Key).Delete(chi.URLParam(r, "category"))
+		if err == pg.ErrNoRows {
+			http.Error(w, errors.ErrMappingNotFound.Error(), 404)
+			return
+		}
+		if err != nil {
+			logger.FromContext(ctx, consts.Logger_Component_Key).Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		w.WriteHeader(204)
+	}
+}
+
+// UnmappedCategories returns upstream categories without mapping. Param: active
+func (ApiHandlers) UnmappedCategories() http.HandlerFunc {
+	return renderValueCounts("unmapped_categories")
+}
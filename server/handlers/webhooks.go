@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mobilda/consts"
+	"mobilda/errors"
+	"mobilda/geo"
+	"mobilda/model"
+	"mobilda/webhooks"
+
+	"bitbucket.org/mobio/go-dbmanager"
+	"bitbucket.org/mobio/go-logger"
+	"github.com/pressly/chi"
+	"gopkg.in/pg.v5"
+)
+
+// webhookBody is a webhook create or update request, empty secret generates a new one on create
+type webhookBody struct {
+	Url       string   `json:"url"`
+	Secret    string   `json:"secret"`
+	Accounts  []int    `json:"accounts"`
+	Countries []string `json:"countries"`
+	Types     []string `json:"types"`
+	IsActive  *bool    `json:"is_active"`
+}
+
+func (b webhookBody) apply(hook *model.Webhook) {
+	hook.Url = b.Url
+	hook.Accounts = b.Accounts
+	hook.Countries = geo.Codes(b.Countries, geo.Country)
+	hook.Types = b.Types
+	if b.Secret != "" {
+		hook.Secret = b.Secret
+	}
+	if b.IsActive != nil {
+		hook.IsActive = *b.IsActive
+	}
+}
+
+// Webhooks returns registered webhooks, secrets are not shown
+func (ApiHandlers) Webhooks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		db := dbmanager.FromContext(ctx, consts.DbManager_Component_Key)
+
+		hooks := []model.Webhook{}
+		if err := db.Model(&hooks).Order("id").Select(); err != nil {
+			logger.FromContext(ctx, consts.Logger_Component_Key).Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+		for i := range hooks {
+			hooks[i].Secret = ""
+		}
+
+		renderJSON(w, 200, hooks)
+	}
+}
+
+// Webhook returns the webhook by id
+func (ApiHandlers) Webhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hook, ok := loadWebhook(w, r)
+		if !ok {
+			return
+		}
+		hook.Secret = ""
+		renderJSON(w, 200, hook)
+	}
+}
+
+// CreateWebhook registers a webhook, body: {"url", "secret", "accounts", "countries", "types"}.
+// The secret is returned only here.
+func (ApiHandlers) CreateWebhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		db := dbmanager.FromContext(ctx, consts.DbManager_Component_Key)
+		log := logger.FromContext(ctx, consts.Logger_Component_Key)
+
+		body := webhookBody{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", 400)
+			return
+		}
+
+		hook := model.Webhook{IsActive: true, CreatedAt: time.Now()}
+		body.apply(&hook)
+		if err := webhooks.Validate(hook); err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		if hook.Secret == "" {
+			secret, err := webhooks.NewSecret()
+			if err != nil {
+				log.Error(err)
+				http.Error(w, "Server error", 500)
+				return
+			}
+			hook.Secret = secret
+		}
+
+		if err := db.Insert(&hook); err != nil {
+			log.Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		renderJSON(w, 201, hook)
+	}
+}
+
+// UpdateWebhook replaces webhook url and filters, secret and is_active are changed when given
+func (ApiHandlers) UpdateWebhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		db := dbmanager.FromContext(ctx, consts.DbManager_Component_Key)
+
+		body := webhookBody{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", 400)
+			return
+		}
+
+		hook, ok := loadWebhook(w, r)
+		if !ok {
+			return
+		}
+		body.apply(&hook)
+		if err := webhooks.Validate(hook); err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+
+		if err := db.Update(&hook); err != nil {
+			logger.FromContext(ctx, consts.Logger_Component_Key).Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		hook.Secret = ""
+		renderJSON(w, 200, hook)
+	}
+}
+
+// DeleteWebhook removes the webhook with its outbox records
+func (ApiHandlers) DeleteWebhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		db := dbmanager.FromContext(ctx, consts.DbManager_Component_Key)
+
+		hook, ok := loadWebhook(w, r)
+		if !ok {
+			return
+		}
+		if err := db.Delete(&hook); err != nil {
+			logger.FromContext(ctx, consts.Logger_Component_Key).Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		w.WriteHeader(204)
+	}
+}
+
+// WebhookDeliveries returns deliveries of the webhook, newest first.
+// Filters: status (pending, delivered, dead), limit, offset
+func (ApiHandlers) WebhookDeliveries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		db := dbmanager.FromContext(ctx, consts.DbManager_Component_Key)
+
+		hook, ok := loadWebhook(w, r)
+		if !ok {
+			return
+		}
+		limit, offset, err := queryLimit(r)
+		if err != nil {
+			http.Error(w, "Invalid limit or offset", 400)
+			return
+		}
+
+		deliveries := []model.WebhookDelivery{}
+		query := db.Model(&deliveries).
+			Where("webhook_id = ?", hook.Id).
+			Order("id DESC").
+			Limit(limit).
+			Offset(offset)
+		if v := r.URL.Query().Get("status"); v != "" {
+			query.Where("status = ?", v)
+		}
+
+		if err := query.Select(); err != nil {
+			logger.FromContext(ctx, consts.Logger_Component_Key).Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+
+		renderJSON(w, 200, deliveries)
+	}
+}
+
+// RetryWebhookDelivery returns a dead or delivered record to the outbox with reset attempts
+func (ApiHandlers) RetryWebhookDelivery() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		db := dbmanager.FromContext(ctx, consts.DbManager_Component_Key)
+
+		hook, ok := loadWebhook(w, r)
+		if !ok {
+			return
+		}
+		id, err := strconv.ParseInt(chi.URLParam(r, "delivery"), 10, 64)
+		if err != nil {
+			http.Error(w, errors.ErrDeliveryNotFound.Error(), 404)
+			return
+		}
+
+		delivery := model.WebhookDelivery{}
+		res, err := db.Model(&delivery).
+			Set("status = ?", model.DeliveryStatusPending).
+			Set("attempts = 0").
+			Set("next_attempt_at = ?", time.Now()).
+			Where("id = ?", id).
+			Where("webhook_id = ?", hook.Id).
+			Returning("*").
+			Update()
+		if err != nil {
+			logger.FromContext(ctx, consts.Logger_Component_Key).Error(err)
+			http.Error(w, "Server error", 500)
+			return
+		}
+		if res.RowsAffected() == 0 {
+			http.Error(w, errors.ErrDeliveryNotFound.Error(), 404)
+			return
+		}
+
+		renderJSON(w, 200, delivery)
+	}
+}
+
+// loadWebhook loads the webhook of the id url param or writes the error
+func loadWebhook(w http.ResponseWriter, r *http.Request) (model.Webhook, bool) {
+	ctx := r.Context()
+	db := dbmanager.FromContext(ctx, consts.DbManager_Component_Key)
+
+	hook := model.Webhook{}
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, errors.ErrWebhookNotFound.Error(), 404)
+		return hook, false
+	}
+
+	hook.Id = id
+	if err := db.Select(&hook); err != nil {
+		if err == pg.ErrNoRows {
+			http.Error(w, errors.ErrWebhookNotFound.Error(), 404)
+		} else {
+			logger.FromContext(ctx, consts.Logger_Component_Key).Error(err)
+			http.Error(w, "Server error", 500)
+		}
+		return hook, false
+	}
+	return hook, true
+}
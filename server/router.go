@@ -10,4 +10,52 @@ var (
 
 func (srv *AppServer) InitRouter() {
 	srv.Router.Post("/run/:collector", ah.RunCollector())
+	srv.Router.Get("/jobs/:id", ah.Job())
+	srv.Router.Delete("/jobs/:id", ah.CancelJob())
+
+	srv.Router.Get("/collectors", ah.Collectors())
+	srv.Router.Get("/collectors/:collector", ah.Collector())
+	srv.Router.Put("/collectors/:collector/interval", ah.RescheduleCollector())
+	srv.Router.Post("/collectors/:collector/pause", ah.PauseCollector())
+	srv.Router.Post("/collectors/:collector/resume", ah.ResumeCollector())
+	srv.Router.Get("/runs", ah.CollectorRuns())
+
+	srv.Router.Get("/offers", ah.Offers())
+	srv.Router.Get("/offers/export", ah.ExportOffers())
+	srv.Router.Get("/offers/search", ah.SearchOffers())
+	srv.Router.Get("/offers/match", ah.MatchOffers())
+	srv.Router.Get("/offers/targeting/unparsed", ah.UnparsedTargeting())
+	srv.Router.Get("/offers/geo/unknown", ah.UnknownGeo())
+	srv.Router.Get("/offers/:account/:offer_id", ah.Offer())
+	srv.Router.Get("/offers/:account/:offer_id/payouts", ah.OfferPayouts())
+
+	srv.Router.Get("/overrides", ah.OfferOverrides())
+	srv.Router.Get("/overrides/:account/:offer_id", ah.OfferOverride())
+	srv.Router.Put("/overrides/:account/:offer_id", ah.SetOfferOverride())
+	srv.Router.Delete("/overrides/:account/:offer_id", ah.DeleteOfferOverride())
+	srv.Router.Get("/overrides/:account/:offer_id/audit", ah.OfferOverrideAudit())
+
+	srv.Router.Get("/changes", ah.Changes())
+	srv.Router.Get("/events", ah.OfferEvents())
+
+	srv.Router.Get("/holds", ah.Holds())
+	srv.Router.Get("/holds/:id", ah.Hold())
+	srv.Router.Post("/holds/:id/approve", ah.ApproveHold())
+	srv.Router.Post("/holds/:id/reject", ah.RejectHold())
+
+	srv.Router.Get("/apps", ah.Apps())
+	srv.Router.Get("/apps/:id", ah.App())
+
+	srv.Router.Get("/categories/mappings", ah.CategoryMappings())
+	srv.Router.Put("/categories/mappings/:category", ah.SetCategoryMapping())
+	srv.Router.Delete("/categories/mappings/:category", ah.DeleteCategoryMapping())
+	srv.Router.Get("/categories/unmapped", ah.UnmappedCategories())
+
+	srv.Router.Get("/webhooks", ah.Webhooks())
+	srv.Router.Post("/webhooks", ah.CreateWebhook())
+	srv.Router.Get("/webhooks/:id", ah.Webhook())
+	srv.Router.Put("/webhooks/:id", ah.UpdateWebhook())
+	srv.Router.Delete("/webhooks/:id", ah.DeleteWebhook())
+	srv.Router.Get("/webhooks/:id/deliveries", ah.WebhookDeliveries())
+	srv.Router.Post("/webhooks/:id/deliveries/:delivery/retry", ah.RetryWebhookDelivery())
 }
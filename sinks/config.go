@@ -0,0 +1,86 @@
+package sinks
+
+import (
+	"context"
+
+	"mobilda/errors"
+	"mobilda/storage"
+
+	"bitbucket.org/mobio/go-logger"
+)
+
+const (
+	TypePostgres = "postgres"
+	TypeNDJSON   = "ndjson"
+	TypeStdout   = "stdout"
+	TypeFanOut   = "fanout"
+)
+
+// Config is a sink entry of the sinks list in app.yaml
+type Config struct {
+	Type string `mapstructure:"type"`
+	Path string `mapstructure:"path"`
+}
+
+// New returns the sink of configs, a fan-out if there are several. Postgres is used if configs are empty.
+func New(configs []Config, repo storage.Repository, l *logger.Logger) (Sink, error) {
+	if len(configs) == 0 {
+		configs = []Config{{Type: TypePostgres}}
+	}
+	if err := Validate(configs); err != nil {
+		return nil, err
+	}
+
+	list := []Sink{}
+	for _, c := range configs {
+		var sink Sink
+		switch c.Type {
+		case TypePostgres:
+			sink = NewRepository(repo)
+		case TypeStdout:
+			sink = NewStdout()
+		case TypeNDJSON:
+			file, err := NewFile(c.Path)
+			if err != nil {
+				return nil, err
+			}
+			sink = file
+		}
+		list = append(list, sink)
+	}
+
+	if len(list) == 1 {
+		return list[0], nil
+	}
+	return NewFanOut(l, list...), nil
+}
+
+// Validate checks sink configs. Postgres must be the first and the only postgres sink: it assigns the change seq
+// sinks after it write, and its error fails the batch, so offers are compared with the written state.
+// Deployments without postgres are not supported, the collector reads active offers, holds and runs
+// from the repository as well, so the other sinks only mirror the postgres writes.
+func Validate(configs []Config) error {
+	for i, c := range configs {
+		switch c.Type {
+		case TypePostgres:
+			if i > 0 {
+				return errors.ErrSinkPostgresFirst
+			}
+		case TypeStdout:
+		case TypeNDJSON:
+			if c.Path == "" {
+				return errors.ErrSinkPathRequired
+			}
+		default:
+			return errors.ErrSinkUnknown
+		}
+	}
+	if len(configs) == 0 || configs[0].Type != TypePostgres {
+		return errors.ErrSinkPostgresFirst
+	}
+	return nil
+}
+
+func FromContext(ctx context.Context, key string) Sink {
+	return ctx.Value(key).(Sink)
+}
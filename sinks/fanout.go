@@ -0,0 +1,57 @@
+package sinks
+
+import (
+	"mobilda/model"
+
+	"bitbucket.org/mobio/go-logger"
+)
+
+// FanOut writes batches to every sink in order. The first sink is primary, postgres in configured sinks:
+// its error fails the batch and the rest are not written. Errors of the rest are logged, so a broken
+// secondary sink does not stop the collection. Batches the repository already wrote are mirrored
+// to the other sinks.
+type FanOut struct {
+	sinks []Sink
+	log   *logger.Logger
+}
+
+func NewFanOut(l *logger.Logger, sinks ...Sink) *FanOut {
+	return &FanOut{sinks: sinks, log: l}
+}
+
+func (this *FanOut) Name() string {
+	return TypeFanOut
+}
+
+func (this *FanOut) Write(b *model.OfferBatch) error {
+	for i, sink := range this.sinks {
+		if err := sink.Write(b); err != nil {
+			if i == 0 {
+				return err
+			}
+			this.log.WithField("sink", sink.Name()).Error(err)
+		}
+	}
+	return nil
+}
+
+func (this *FanOut) Mirror(b *model.OfferBatch) {
+	for _, sink := range this.sinks {
+		if _, ok := sink.(*Repository); ok {
+			continue
+		}
+		if err := sink.Write(b); err != nil {
+			this.log.WithField("sink", sink.Name()).Error(err)
+		}
+	}
+}
+
+func (this *FanOut) Close() error {
+	var first error
+	for _, sink := range this.sinks {
+		if err := sink.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
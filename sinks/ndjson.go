@@ -0,0 +1,68 @@
+package sinks
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"mobilda/model"
+)
+
+// NDJSON writes a line per change event: the offer event as served by the change feed
+type NDJSON struct {
+	lock   sync.Mutex
+	name   string
+	w      *bufio.Writer
+	enc    *json.Encoder
+	closer io.Closer
+}
+
+func NewNDJSON(name string, w io.Writer, closer io.Closer) *NDJSON {
+	bw := bufio.NewWriter(w)
+	return &NDJSON{name: name, w: bw, enc: json.NewEncoder(bw), closer: closer}
+}
+
+// NewFile appends events to the file
+func NewFile(path string) (*NDJSON, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return NewNDJSON(TypeNDJSON+":"+path, f, f), nil
+}
+
+func NewStdout() *NDJSON {
+	return NewNDJSON(TypeStdout, os.Stdout, nil)
+}
+
+func (this *NDJSON) Name() string {
+	return this.name
+}
+
+// Write writes and flushes events of the batch, accounts are collected concurrently
+func (this *NDJSON) Write(b *model.OfferBatch) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	for _, e := range b.Events {
+		if err := this.enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return this.w.Flush()
+}
+
+func (this *NDJSON) Close() error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if err := this.w.Flush(); err != nil {
+		return err
+	}
+	if this.closer != nil {
+		return this.closer.Close()
+	}
+	return nil
+}
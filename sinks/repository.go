@@ -0,0 +1,28 @@
+package sinks
+
+import (
+	"mobilda/model"
+	"mobilda/storage"
+)
+
+// Repository writes offers to the collectors repository, it is the postgres sink of app.yaml.
+// It keeps the state the collector compares loaded offers with.
+type Repository struct {
+	repo storage.Repository
+}
+
+func NewRepository(repo storage.Repository) *Repository {
+	return &Repository{repo: repo}
+}
+
+func (this *Repository) Name() string {
+	return TypePostgres
+}
+
+func (this *Repository) Write(b *model.OfferBatch) error {
+	return this.repo.WriteOffers(b)
+}
+
+func (this *Repository) Close() error {
+	return nil
+}
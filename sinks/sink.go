@@ -0,0 +1,20 @@
+// Package sinks publishes offer changes collected by OffersCollector
+package sinks
+
+import (
+	"mobilda/model"
+)
+
+// Sink receives upserted and stopped offers of an account
+type Sink interface {
+	Name() string
+	// Write publishes the batch, a failed batch is retried by the collector offer by offer
+	Write(b *model.OfferBatch) error
+	Close() error
+}
+
+// Mirror is implemented by sinks writing batches the repository already wrote, like approved held changes
+type Mirror interface {
+	// Mirror writes the batch to sinks other than the repository, their errors are logged
+	Mirror(b *model.OfferBatch)
+}
@@ -0,0 +1,65 @@
+package sinks
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"mobilda/errors"
+	"mobilda/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testBatch() *model.OfferBatch {
+	return &model.OfferBatch{
+		Inserted: []model.Offer{{Id: 1, AccountId: 1}},
+		Updated:  []model.Offer{{Id: 2, AccountId: 1}, {Id: 3, AccountId: 1}},
+		Stopped:  []model.Offer{{Id: 4, AccountId: 1}},
+	}
+}
+
+func TestNDJSON_Write(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sink := NewNDJSON("test", buf, nil)
+
+	b := testBatch()
+	b.NewEvents(time.Now())
+	assert.NoError(t, sink.Write(b))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 4)
+
+	e := model.OfferEvent{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[3]), &e))
+	assert.Equal(t, model.OfferChangeStopped, e.ChangeType)
+	assert.Equal(t, uint64(4), e.Offer.Id)
+	assert.NoError(t, sink.Close())
+}
+
+func TestNew(t *testing.T) {
+	_, err := New([]Config{{Type: "kafka"}}, nil, nil)
+	assert.Equal(t, errors.ErrSinkUnknown, err)
+
+	_, err = New([]Config{{Type: TypeNDJSON}}, nil, nil)
+	assert.Equal(t, errors.ErrSinkPathRequired, err)
+
+	_, err = New([]Config{{Type: TypeStdout}, {Type: TypePostgres}}, nil, nil)
+	assert.Equal(t, errors.ErrSinkPostgresFirst, err)
+
+	_, err = New([]Config{{Type: TypeStdout}}, nil, nil)
+	assert.Equal(t, errors.ErrSinkPostgresFirst, err)
+
+	_, err = New([]Config{{Type: TypePostgres}, {Type: TypePostgres}}, nil, nil)
+	assert.Equal(t, errors.ErrSinkPostgresFirst, err)
+
+	sink, err := New(nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, TypePostgres, sink.Name())
+
+	sink, err = New([]Config{{Type: TypePostgres}, {Type: TypeStdout}}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, TypeFanOut, sink.Name())
+}
@@ -0,0 +1,328 @@
+package storage
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"mobilda/errors"
+	"mobilda/model"
+)
+
+type offerKey struct {
+	accountId int
+	offerId   uint64
+}
+
+// Memory keeps everything in maps, it is used in tests and to run collectors without a database
+type Memory struct {
+	lock     sync.RWMutex
+	accounts map[string]model.Account
+	offers   map[offerKey]model.Offer
+	changes  []model.OfferChange
+	payouts  []model.OfferPayout
+	holds    []model.RunHold
+	held     []model.HeldChange
+	runs     map[int64]model.CollectorRun
+	runSeq   int64
+}
+
+var _ Repository = (*Memory)(nil)
+
+func NewMemory() *Memory {
+	return &Memory{
+		accounts: map[string]model.Account{},
+		offers:   map[offerKey]model.Offer{},
+		runs:     map[int64]model.CollectorRun{},
+	}
+}
+
+func (this *Memory) SaveAccounts(accounts []model.Account) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	for _, acc := range accounts {
+		if _, ok := this.accounts[acc.Name]; !ok {
+			this.accounts[acc.Name] = acc
+		}
+	}
+	return nil
+}
+
+// Accounts returns saved accounts ordered by id
+func (this *Memory) Accounts() []model.Account {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	accounts := []model.Account{}
+	for _, acc := range this.accounts {
+		accounts = append(accounts, acc)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Id < accounts[j].Id })
+	return accounts
+}
+
+func (this *Memory) OfferHashes() ([]model.Offer, error) {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	offers := []model.Offer{}
+	for _, offer := range this.offers {
+		offers = append(offers, model.Offer{Id: offer.Id, AccountId: offer.AccountId, Hash: offer.Hash})
+	}
+	return offers, nil
+}
+
+func (this *Memory) ActiveOffers(accountId int, except []uint64) ([]model.Offer, error) {
+	skip := map[uint64]bool{}
+	for _, id := range except {
+		skip[id] = true
+	}
+
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	offers := []model.Offer{}
+	for key, offer := range this.offers {
+		if key.accountId == accountId && offer.IsActive == model.OfferStatusActive && !skip[key.offerId] {
+			offers = append(offers, offer)
+		}
+	}
+	sort.Slice(offers, func(i, j int) bool { return offers[i].Id < offers[j].Id })
+	return offers, nil
+}
+
+// Offer returns the stored offer
+func (this *Memory) Offer(accountId int, offerId uint64) (model.Offer, bool) {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	offer, ok := this.offers[offerKey{accountId, offerId}]
+	return offer, ok
+}
+
+func (this *Memory) WriteOffers(b *model.OfferBatch) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.writeOffers(b)
+}
+
+func (this *Memory) writeOffers(b *model.OfferBatch) error {
+	for _, offer := range b.Inserted {
+		if _, ok := this.offers[offerKey{offer.AccountId, offer.Id}]; ok {
+			return errors.ErrOfferExists
+		}
+	}
+
+	reactivated := map[uint64]bool{}
+	previous := map[uint64]model.Offer{}
+	for _, offer := range append(append([]model.Offer{}, b.Updated...), b.Stopped...) {
+		stored, ok := this.offers[offerKey{offer.AccountId, offer.Id}]
+		if !ok {
+			continue
+		}
+		previous[offer.Id] = stored
+		if stored.IsActive == model.OfferStatusStopped {
+			reactivated[offer.Id] = true
+		}
+	}
+	b.NewPayouts(previous, time.Now())
+	this.payouts = append(this.payouts, b.PayoutPoints()...)
+
+	for _, list := range [][]model.Offer{b.Inserted, b.Updated, b.Stopped} {
+		for _, offer := range list {
+			this.offers[offerKey{offer.AccountId, offer.Id}] = offer
+		}
+	}
+
+	b.MarkReactivated(reactivated)
+	for i := range b.Events {
+		b.Events[i].Seq = int64(len(this.changes) + 1)
+		this.changes = append(this.changes, b.Events[i].OfferChange)
+	}
+	return nil
+}
+
+// Changes returns the change feed
+func (this *Memory) Changes() []model.OfferChange {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return append([]model.OfferChange{}, this.changes...)
+}
+
+// Payouts returns recorded payout points
+func (this *Memory) Payouts() []model.OfferPayout {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return append([]model.OfferPayout{}, this.payouts...)
+}
+
+func (this *Memory) HoldChanges(hold *model.RunHold, b *model.OfferBatch, previous map[uint64]model.Offer) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.supersedeHolds(hold.AccountId, hold.CreatedAt)
+	hold.Id = int64(len(this.holds) + 1)
+	this.holds = append(this.holds, *hold)
+	for _, change := range b.HeldChanges(hold.Id, previous) {
+		change.Id = int64(len(this.held) + 1)
+		// offers are kept as json like in postgres, without their hash
+		data, err := json.Marshal(change.Offer)
+		if err != nil {
+			return err
+		}
+		change.Offer = model.Offer{}
+		if err := json.Unmarshal(data, &change.Offer); err != nil {
+			return err
+		}
+		this.held = append(this.held, change)
+	}
+	return nil
+}
+
+func (this *Memory) SupersedeHolds(accountId int, at time.Time) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.supersedeHolds(accountId, at)
+	return nil
+}
+
+func (this *Memory) supersedeHolds(accountId int, at time.Time) {
+	for i := range this.holds {
+		if this.holds[i].AccountId == accountId && this.holds[i].Status == model.HoldStatusHeld {
+			this.holds[i].Status = model.HoldStatusSuperseded
+			this.holds[i].ResolvedAt = &at
+		}
+	}
+}
+
+func (this *Memory) Hold(id int64) (*model.RunHold, error) {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	if id <= 0 || id > int64(len(this.holds)) {
+		return nil, errors.ErrHoldNotFound
+	}
+	hold := this.holds[id-1]
+	return &hold, nil
+}
+
+// pendingChanges returns indexes of pending changes of the offers and the changes, all of them if offers are empty
+func (this *Memory) pendingChanges(holdId int64, offers []uint64) ([]int, []model.HeldChange, error) {
+	if holdId <= 0 || holdId > int64(len(this.holds)) {
+		return nil, nil, errors.ErrHoldNotFound
+	}
+	if this.holds[holdId-1].Status != model.HoldStatusHeld {
+		return nil, nil, errors.ErrHoldResolved
+	}
+
+	selected := map[uint64]bool{}
+	for _, id := range offers {
+		selected[id] = true
+	}
+	indexes, changes := []int{}, []model.HeldChange{}
+	for i, change := range this.held {
+		if change.HoldId == holdId && change.Status == model.ChangeStatusPending && (len(offers) == 0 || selected[change.OfferId]) {
+			indexes = append(indexes, i)
+			changes = append(changes, change)
+		}
+	}
+	if len(changes) == 0 || len(offers) > 0 && len(changes) != len(selected) {
+		return nil, nil, errors.ErrHeldChangeNotFound
+	}
+	return indexes, changes, nil
+}
+
+func (this *Memory) ResolveHeld(holdId int64, offers []uint64, approve bool, reason string) (*model.RunHold, *model.OfferBatch, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	indexes, changes, err := this.pendingChanges(holdId, offers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	status := model.ChangeStatusRejected
+	b := model.BatchOf(changes)
+	if approve {
+		status = model.ChangeStatusApproved
+		stored := map[uint64]model.Offer{}
+		for _, change := range changes {
+			if offer, ok := this.offers[offerKey{change.AccountId, change.OfferId}]; ok {
+				stored[offer.Id] = offer
+			}
+		}
+		b = model.ApprovedBatch(changes, stored, now)
+		if b.Size() > 0 {
+			b.NewEvents(now)
+			if err := this.writeOffers(b); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	for _, i := range indexes {
+		this.held[i].Status = status
+		this.held[i].Reason = reason
+		this.held[i].ResolvedAt = &now
+	}
+
+	all := []model.HeldChange{}
+	for _, change := range this.held {
+		if change.HoldId == holdId {
+			all = append(all, change)
+		}
+	}
+	hold := &this.holds[holdId-1]
+	hold.Status = model.HoldStatus(all)
+	if hold.Status != model.HoldStatusHeld {
+		hold.ResolvedAt = &now
+	}
+
+	resolved := *hold
+	return &resolved, b, nil
+}
+
+// Holds returns stored holds
+func (this *Memory) Holds() []model.RunHold {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return append([]model.RunHold{}, this.holds...)
+}
+
+// HeldChanges returns changes of the hold
+func (this *Memory) HeldChanges(holdId int64) []model.HeldChange {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	changes := []model.HeldChange{}
+	for _, change := range this.held {
+		if change.HoldId == holdId {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+func (this *Memory) InsertRun(run *model.CollectorRun) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.runSeq++
+	run.Id = this.runSeq
+	this.runs[run.Id] = *run
+	return nil
+}
+
+func (this *Memory) UpdateRun(run *model.CollectorRun) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if _, ok := this.runs[run.Id]; !ok {
+		return errors.ErrRunNotFound
+	}
+	this.runs[run.Id] = *run
+	return nil
+}
+
+// Runs returns stored runs ordered by id
+func (this *Memory) Runs() []model.CollectorRun {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	runs := []model.CollectorRun{}
+	for _, run := range this.runs {
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Id < runs[j].Id })
+	return runs
+}
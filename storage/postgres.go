@@ -0,0 +1,462 @@
+package storage
+
+import (
+	"time"
+
+	"mobilda/errors"
+	"mobilda/model"
+	"mobilda/notify"
+	"mobilda/webhooks"
+
+	"bitbucket.org/mobio/go-dbmanager"
+	"gopkg.in/pg.v5"
+)
+
+// changeFeedLock is the advisory lock key taken by every transaction writing the change feed.
+// Change sequence is allocated under the lock, so the feed never gets a lower seq committed later.
+const changeFeedLock = 7135001
+
+// Postgres writes offers with their change feed, webhook outbox and notifications in a single transaction
+type Postgres struct {
+	db *dbmanager.DbManager
+}
+
+var _ Repository = (*Postgres)(nil)
+
+func NewPostgres(db *dbmanager.DbManager) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (this *Postgres) SaveAccounts(accounts []model.Account) error {
+	if len(accounts) == 0 {
+		return nil
+	}
+	_, err := this.db.Model(&accounts).OnConflict("(name) DO NOTHING").Insert()
+	return err
+}
+
+func (this *Postgres) OfferHashes() ([]model.Offer, error) {
+	offers := []model.Offer{}
+	err := this.db.Model(&offers).
+		Column("offer_id", "account_id", "hash").
+		Select()
+	return offers, err
+}
+
+func (this *Postgres) ActiveOffers(accountId int, except []uint64) ([]model.Offer, error) {
+	offers := []model.Offer{}
+	query := this.db.
+		Model(&offers).
+		Where("is_active = ?", model.OfferStatusActive).
+		Where("account_id = ?", accountId)
+
+	if len(except) > 0 {
+		query.WhereIn("offer_id NOT IN (?)", ids(except)...)
+	}
+
+	err := query.Select()
+	return offers, err
+}
+
+func (this *Postgres) WriteOffers(b *model.OfferBatch) error {
+	return this.db.RunInTransaction(func(tx *pg.Tx) error {
+		return this.writeOffers(tx, b)
+	})
+}
+
+func (this *Postgres) writeOffers(tx *pg.Tx, b *model.OfferBatch) error {
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock(?)", changeFeedLock); err != nil {
+		return err
+	}
+
+	// reactivation and payout changes are checked before the offers are updated
+	reactivated, err := this.stoppedOffers(tx, b.Updated)
+	if err != nil {
+		return err
+	}
+	previous, err := this.storedPayouts(tx, append(append([]model.Offer{}, b.Updated...), b.Stopped...))
+	if err != nil {
+		return err
+	}
+	b.NewPayouts(previous, time.Now())
+
+	if err := this.linkApps(tx, b); err != nil {
+		return err
+	}
+
+	if len(b.Inserted) > 0 {
+		if _, err := tx.Model(&b.Inserted).Insert(); err != nil {
+			return err
+		}
+	}
+	for i := range b.Updated {
+		if err := tx.Update(&b.Updated[i]); err != nil {
+			return err
+		}
+	}
+	for i := range b.Stopped {
+		if err := tx.Update(&b.Stopped[i]); err != nil {
+			return err
+		}
+	}
+	if err := this.updateSearch(tx, b); err != nil {
+		return err
+	}
+
+	if points := b.PayoutPoints(); len(points) > 0 {
+		if _, err := tx.Model(&points).Insert(); err != nil {
+			return err
+		}
+	}
+
+	b.MarkReactivated(reactivated)
+	changes := make([]model.OfferChange, len(b.Events))
+	for i := range b.Events {
+		changes[i] = b.Events[i].OfferChange
+	}
+	if len(changes) > 0 {
+		if _, err := tx.Model(&changes).Insert(); err != nil {
+			return err
+		}
+	}
+	for i := range b.Events {
+		b.Events[i].Seq = changes[i].Seq
+	}
+
+	if err := webhooks.Enqueue(tx, b.Events); err != nil {
+		return err
+	}
+	return notify.Publish(tx, b.Events)
+}
+
+// updateSearch refreshes search vector of written offers, offers stopped by an override may change other fields
+func (this *Postgres) updateSearch(tx *pg.Tx, b *model.OfferBatch) error {
+	offers := append(append(append([]model.Offer{}, b.Inserted...), b.Updated...), b.Stopped...)
+	if len(offers) == 0 {
+		return nil
+	}
+
+	list := make([]uint64, len(offers))
+	for i, offer := range offers {
+		list[i] = offer.Id
+	}
+
+	_, err := tx.Model(&model.Offer{}).
+		Set("search_vector = "+model.OfferSearchVector).
+		Where("account_id = ?", offers[0].AccountId).
+		WhereIn("offer_id IN (?)", ids(list)...).
+		Update()
+	return err
+}
+
+// linkApps creates missing canonical apps of written offers and sets offer app ids
+func (this *Postgres) linkApps(tx *pg.Tx, b *model.OfferBatch) error {
+	offers := []*model.Offer{}
+	for i := range b.Inserted {
+		offers = append(offers, &b.Inserted[i])
+	}
+	for i := range b.Updated {
+		offers = append(offers, &b.Updated[i])
+	}
+	for i := range b.Stopped {
+		offers = append(offers, &b.Stopped[i])
+	}
+
+	now := time.Now()
+	apps := []model.App{}
+	keys := map[string]bool{}
+	storeAppIds := []interface{}{}
+	for _, offer := range offers {
+		app, ok := model.AppOf(*offer, now)
+		if !ok || keys[app.Key()] {
+			continue
+		}
+		keys[app.Key()] = true
+		apps = append(apps, app)
+		storeAppIds = append(storeAppIds, app.StoreAppId)
+	}
+	if len(apps) == 0 {
+		return nil
+	}
+
+	_, err := tx.Model(&apps).
+		OnConflict("(store, store_app_id) DO UPDATE").
+		Set("platform = COALESCE(EXCLUDED.platform, app.platform)").
+		Set("bundle_id = COALESCE(EXCLUDED.bundle_id, app.bundle_id)").
+		Set("title = COALESCE(EXCLUDED.title, app.title)").
+		Set("updated_at = EXCLUDED.updated_at").
+		Insert()
+	if err != nil {
+		return err
+	}
+
+	found := []model.App{}
+	err = tx.Model(&found).
+		Column("id", "store", "store_app_id").
+		WhereIn("store_app_id IN (?)", storeAppIds...).
+		Select()
+	if err != nil {
+		return err
+	}
+	appIds := map[string]int64{}
+	for _, app := range found {
+		appIds[app.Key()] = app.Id
+	}
+
+	for _, offer := range offers {
+		offer.AppId = 0
+		if app, ok := model.AppOf(*offer, now); ok {
+			offer.AppId = appIds[app.Key()]
+		}
+	}
+	return nil
+}
+
+// stoppedOffers returns ids of offers which are stopped in the database
+func (this *Postgres) stoppedOffers(tx *pg.Tx, offers []model.Offer) (map[uint64]bool, error) {
+	stopped := map[uint64]bool{}
+	if len(offers) == 0 {
+		return stopped, nil
+	}
+
+	list := make([]uint64, len(offers))
+	for i, offer := range offers {
+		list[i] = offer.Id
+	}
+
+	found := []model.Offer{}
+	err := tx.Model(&found).
+		Column("offer_id").
+		Where("account_id = ?", offers[0].AccountId).
+		Where("is_active = ?", model.OfferStatusStopped).
+		WhereIn("offer_id IN (?)", ids(list)...).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, offer := range found {
+		stopped[offer.Id] = true
+	}
+	return stopped, nil
+}
+
+// storedPayouts returns stored payout, rate, currency, business model and upstream values of offers by id
+func (this *Postgres) storedPayouts(tx *pg.Tx, offers []model.Offer) (map[uint64]model.Offer, error) {
+	stored := map[uint64]model.Offer{}
+	if len(offers) == 0 {
+		return stored, nil
+	}
+
+	list := make([]uint64, len(offers))
+	for i, offer := range offers {
+		list[i] = offer.Id
+	}
+
+	found := []model.Offer{}
+	err := tx.Model(&found).
+		Column("offer_id", "account_id", "payout", "rate", "currency", "business_model", "upstream").
+		Where("account_id = ?", offers[0].AccountId).
+		WhereIn("offer_id IN (?)", ids(list)...).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, offer := range found {
+		stored[offer.Id] = offer
+	}
+	return stored, nil
+}
+
+func (this *Postgres) HoldChanges(hold *model.RunHold, b *model.OfferBatch, previous map[uint64]model.Offer) error {
+	return this.db.RunInTransaction(func(tx *pg.Tx) error {
+		if err := this.supersedeHolds(tx, hold.AccountId, hold.CreatedAt); err != nil {
+			return err
+		}
+
+		if _, err := tx.Model(hold).Insert(); err != nil {
+			return err
+		}
+		changes := b.HeldChanges(hold.Id, previous)
+		if len(changes) == 0 {
+			return nil
+		}
+		_, err := tx.Model(&changes).Insert()
+		return err
+	})
+}
+
+func (this *Postgres) SupersedeHolds(accountId int, at time.Time) error {
+	return this.db.RunInTransaction(func(tx *pg.Tx) error {
+		return this.supersedeHolds(tx, accountId, at)
+	})
+}
+
+func (this *Postgres) supersedeHolds(tx *pg.Tx, accountId int, at time.Time) error {
+	_, err := tx.Model(&model.RunHold{}).
+		Set("status = ?", model.HoldStatusSuperseded).
+		Set("resolved_at = ?", at).
+		Where("account_id = ?", accountId).
+		Where("status = ?", model.HoldStatusHeld).
+		Update()
+	return err
+}
+
+func (this *Postgres) Hold(id int64) (*model.RunHold, error) {
+	hold := &model.RunHold{}
+	err := this.db.Model(hold).Where("id = ?", id).Select()
+	if err == pg.ErrNoRows {
+		return nil, errors.ErrHoldNotFound
+	}
+	return hold, err
+}
+
+// pendingChanges returns the held run and its pending changes of the offers, all of them if offers are empty
+func (this *Postgres) pendingChanges(tx *pg.Tx, holdId int64, offers []uint64) (*model.RunHold, []model.HeldChange, error) {
+	hold := &model.RunHold{}
+	err := tx.Model(hold).Where("id = ?", holdId).Select()
+	if err == pg.ErrNoRows {
+		return nil, nil, errors.ErrHoldNotFound
+	} else if err != nil {
+		return nil, nil, err
+	}
+	if hold.Status != model.HoldStatusHeld {
+		return nil, nil, errors.ErrHoldResolved
+	}
+
+	changes := []model.HeldChange{}
+	q := tx.Model(&changes).
+		Where("hold_id = ?", holdId).
+		Where("status = ?", model.ChangeStatusPending).
+		Order("id")
+	if len(offers) > 0 {
+		q.WhereIn("offer_id IN (?)", ids(offers)...)
+	}
+	if err := q.Select(); err != nil {
+		return nil, nil, err
+	}
+	if len(changes) == 0 || len(offers) > 0 && len(changes) != len(distinct(offers)) {
+		return nil, nil, errors.ErrHeldChangeNotFound
+	}
+	return hold, changes, nil
+}
+
+func (this *Postgres) ResolveHeld(holdId int64, offers []uint64, approve bool, reason string) (*model.RunHold, *model.OfferBatch, error) {
+	var hold *model.RunHold
+	var b *model.OfferBatch
+	err := this.db.RunInTransaction(func(tx *pg.Tx) error {
+		// concurrent resolutions of the hold wait for each other
+		if _, err := tx.Exec("SELECT id FROM mobilda.run_hold WHERE id = ? FOR UPDATE", holdId); err != nil {
+			return err
+		}
+		_, err := tx.Exec("SELECT id FROM mobilda.held_change WHERE hold_id = ? AND status = ? FOR UPDATE",
+			holdId, model.ChangeStatusPending)
+		if err != nil {
+			return err
+		}
+		var changes []model.HeldChange
+		if hold, changes, err = this.pendingChanges(tx, holdId, offers); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		status := model.ChangeStatusRejected
+		b = model.BatchOf(changes)
+		if approve {
+			status = model.ChangeStatusApproved
+			stored, err := this.heldOffers(tx, hold.AccountId, changes)
+			if err != nil {
+				return err
+			}
+			b = model.ApprovedBatch(changes, stored, now)
+			if b.Size() > 0 {
+				b.NewEvents(now)
+				if err := this.writeOffers(tx, b); err != nil {
+					return err
+				}
+			}
+		}
+
+		changeIds := make([]interface{}, len(changes))
+		for i, change := range changes {
+			changeIds[i] = change.Id
+		}
+		_, err = tx.Model(&model.HeldChange{}).
+			Set("status = ?", status).
+			Set("reason = ?", reason).
+			Set("resolved_at = ?", now).
+			WhereIn("id IN (?)", changeIds...).
+			Update()
+		if err != nil {
+			return err
+		}
+
+		all := []model.HeldChange{}
+		if err := tx.Model(&all).Column("id", "status").Where("hold_id = ?", holdId).Select(); err != nil {
+			return err
+		}
+		hold.Status = model.HoldStatus(all)
+		if hold.Status != model.HoldStatusHeld {
+			hold.ResolvedAt = &now
+		}
+		_, err = tx.Model(hold).
+			Set("status = ?", hold.Status).
+			Set("resolved_at = ?", hold.ResolvedAt).
+			Where("id = ?", hold.Id).
+			Update()
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return hold, b, nil
+}
+
+// heldOffers returns stored offers of the held changes by id
+func (this *Postgres) heldOffers(tx *pg.Tx, accountId int, changes []model.HeldChange) (map[uint64]model.Offer, error) {
+	list := make([]uint64, len(changes))
+	for i, change := range changes {
+		list[i] = change.OfferId
+	}
+
+	found := []model.Offer{}
+	err := tx.Model(&found).
+		Where("account_id = ?", accountId).
+		WhereIn("offer_id IN (?)", ids(list)...).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	stored := make(map[uint64]model.Offer, len(found))
+	for _, offer := range found {
+		stored[offer.Id] = offer
+	}
+	return stored, nil
+}
+
+func (this *Postgres) InsertRun(run *model.CollectorRun) error {
+	_, err := this.db.Model(run).Insert()
+	return err
+}
+
+func (this *Postgres) UpdateRun(run *model.CollectorRun) error {
+	return this.db.Update(run)
+}
+
+func distinct(list []uint64) map[uint64]bool {
+	set := map[uint64]bool{}
+	for _, v := range list {
+		set[v] = true
+	}
+	return set
+}
+
+func ids(list []uint64) []interface{} {
+	values := make([]interface{}, len(list))
+	for i, v := range list {
+		values[i] = v
+	}
+	return values
+}
@@ -0,0 +1,41 @@
+// Package storage keeps offers and accounts collected by the collectors
+package storage
+
+import (
+	"context"
+	"time"
+
+	"mobilda/model"
+)
+
+// Repository covers offer and account operations of the collectors
+type Repository interface {
+	// SaveAccounts inserts accounts, existing accounts are kept
+	SaveAccounts(accounts []model.Account) error
+
+	// OfferHashes returns id, account and hash of all offers
+	OfferHashes() ([]model.Offer, error)
+	// ActiveOffers returns active offers of the account except the given ids
+	ActiveOffers(accountId int, except []uint64) ([]model.Offer, error)
+	// WriteOffers writes the batch with its change feed atomically.
+	// Reactivated offers are marked and the change seq is assigned to batch events.
+	WriteOffers(b *model.OfferBatch) error
+	// HoldChanges stores the hold with pending changes of the batch, previous are stored states of offers
+	// by id. Held runs of the account are superseded.
+	HoldChanges(hold *model.RunHold, b *model.OfferBatch, previous map[uint64]model.Offer) error
+	// SupersedeHolds supersedes held runs of the account, the latest run reflects the feed
+	SupersedeHolds(accountId int, at time.Time) error
+	// Hold returns the held run by id
+	Hold(id int64) (*model.RunHold, error)
+	// ResolveHeld approves or rejects pending changes of the held run, all of them if offers are empty.
+	// Approved changes are diffed against stored offers and written with their change feed in the same
+	// transaction. It returns the hold with its new status and the written batch, the held one if rejected.
+	ResolveHeld(holdId int64, offers []uint64, approve bool, reason string) (*model.RunHold, *model.OfferBatch, error)
+
+	InsertRun(run *model.CollectorRun) error
+	UpdateRun(run *model.CollectorRun) error
+}
+
+func FromContext(ctx context.Context, key string) Repository {
+	return ctx.Value(key).(Repository)
+}
@@ -0,0 +1,185 @@
+// Package taxonomy translates upstream Mobilda offer categories into internal verticals
+package taxonomy
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mobilda/model"
+
+	"bitbucket.org/mobio/go-dbmanager"
+	"gopkg.in/pg.v5"
+	"gopkg.in/yaml.v2"
+)
+
+// Taxonomy keeps category mappings in memory, mappings are stored in category_mapping table
+type Taxonomy struct {
+	db *dbmanager.DbManager
+
+	lock      sync.RWMutex
+	verticals map[string]string
+}
+
+func New(db *dbmanager.DbManager) *Taxonomy {
+	return &Taxonomy{
+		db:        db,
+		verticals: map[string]string{},
+	}
+}
+
+// Key returns the category mapping key, categories are matched case insensitive
+func Key(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
+
+// Load reloads mappings from the database
+func (t *Taxonomy) Load() error {
+	mappings := []model.CategoryMapping{}
+	if err := t.db.Model(&mappings).Column("category", "vertical", "updated_at").Select(); err != nil {
+		return err
+	}
+
+	verticals := make(map[string]string, len(mappings))
+	for _, m := range mappings {
+		verticals[m.Category] = m.Vertical
+	}
+
+	t.lock.Lock()
+	t.verticals = verticals
+	t.lock.Unlock()
+	return nil
+}
+
+// Bootstrap inserts mappings of the yaml file missing from the database,
+// mappings changed through the api are kept
+func (t *Taxonomy) Bootstrap(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	mappings, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	if len(mappings) == 0 {
+		return nil
+	}
+
+	_, err = t.db.Model(&mappings).OnConflict("(category) DO NOTHING").Insert()
+	return err
+}
+
+// Parse parses yaml of verticals with their upstream categories:
+//
+//	games: [Action, Arcade]
+//
+// a category listed more than once is an error, as it would be mapped to a random vertical
+func Parse(data []byte) ([]model.CategoryMapping, error) {
+	verticals := map[string][]string{}
+	if err := yaml.Unmarshal(data, &verticals); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	names := make([]string, 0, len(verticals))
+	for vertical := range verticals {
+		names = append(names, vertical)
+	}
+	sort.Strings(names)
+
+	seen := map[string]string{}
+	mappings := []model.CategoryMapping{}
+	for _, vertical := range names {
+		for _, category := range verticals[vertical] {
+			key := Key(category)
+			if key == "" {
+				continue
+			}
+			if first, ok := seen[key]; ok {
+				return nil, fmt.Errorf("Category %q is listed under %s and %s", key, first, vertical)
+			}
+			seen[key] = vertical
+			mappings = append(mappings, model.CategoryMapping{Category: key, Vertical: vertical, UpdatedAt: now})
+		}
+	}
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].Category < mappings[j].Category })
+
+	return mappings, nil
+}
+
+// Map returns sorted distinct verticals of categories and categories without mapping
+func (t *Taxonomy) Map(categories []string) (verticals, unmapped []string) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	seen := map[string]bool{}
+	for _, category := range categories {
+		vertical, ok := t.verticals[Key(category)]
+		if !ok {
+			unmapped = append(unmapped, category)
+			continue
+		}
+		if !seen[vertical] {
+			seen[vertical] = true
+			verticals = append(verticals, vertical)
+		}
+	}
+	sort.Strings(verticals)
+
+	return verticals, unmapped
+}
+
+// Apply sets offer verticals and unmapped categories
+func (t *Taxonomy) Apply(offer *model.Offer) {
+	offer.Verticals, offer.UnmappedCategories = t.Map(offer.Categories)
+}
+
+// Mappings returns mappings ordered by category
+func (t *Taxonomy) Mappings() ([]model.CategoryMapping, error) {
+	mappings := []model.CategoryMapping{}
+	err := t.db.Model(&mappings).Column("category", "vertical", "updated_at").Order("category").Select()
+	return mappings, err
+}
+
+// Set creates or updates the category mapping
+func (t *Taxonomy) Set(category, vertical string) (*model.CategoryMapping, error) {
+	m := &model.CategoryMapping{Category: Key(category), Vertical: vertical, UpdatedAt: time.Now()}
+	_, err := t.db.Model(m).
+		OnConflict("(category) DO UPDATE").
+		Set("vertical = EXCLUDED.vertical, updated_at = EXCLUDED.updated_at").
+		Insert()
+	if err != nil {
+		return nil, err
+	}
+
+	t.lock.Lock()
+	t.verticals[m.Category] = m.Vertical
+	t.lock.Unlock()
+	return m, nil
+}
+
+// Delete removes the category mapping, it returns pg.ErrNoRows if the mapping does not exist
+func (t *Taxonomy) Delete(category string) error {
+	key := Key(category)
+	res, err := t.db.Model(&model.CategoryMapping{}).Where("category = ?", key).Delete()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return pg.ErrNoRows
+	}
+
+	t.lock.Lock()
+	delete(t.verticals, key)
+	t.lock.Unlock()
+	return nil
+}
+
+func FromContext(ctx context.Context, key string) *Taxonomy {
+	return ctx.Value(key).(*Taxonomy)
+}
@@ -0,0 +1,47 @@
+package taxonomy
+
+import (
+	"testing"
+
+	"mobilda/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	mappings, err := Parse([]byte("games: [Action, ' arcade ', Games]\nfinance: [Finance]\n"))
+	assert.NoError(t, err)
+
+	verticals := map[string]string{}
+	for _, m := range mappings {
+		verticals[m.Category] = m.Vertical
+	}
+	assert.Len(t, mappings, 4)
+	assert.Equal(t, "games", verticals["arcade"])
+	assert.Equal(t, "finance", verticals["finance"])
+	assert.Equal(t, "games", verticals["action"])
+
+	_, err = Parse([]byte("games: [Action, Arcade]\nfinance: [Finance, action]\n"))
+	assert.EqualError(t, err, `Category "action" is listed under finance and games`)
+
+	_, err = Parse([]byte("games: Action"))
+	assert.Error(t, err)
+}
+
+func TestMap(t *testing.T) {
+	tx := New(nil)
+	tx.verticals = map[string]string{"action": "games", "arcade": "games", "finance": "finance"}
+
+	verticals, unmapped := tx.Map([]string{"Finance", "Action", "ARCADE", "Weather"})
+	assert.Equal(t, []string{"finance", "games"}, verticals)
+	assert.Equal(t, []string{"Weather"}, unmapped)
+
+	verticals, unmapped = tx.Map(nil)
+	assert.Nil(t, verticals)
+	assert.Nil(t, unmapped)
+
+	offer := model.Offer{Categories: []string{"Arcade"}}
+	tx.Apply(&offer)
+	assert.Equal(t, []string{"games"}, offer.Verticals)
+	assert.Nil(t, offer.UnmappedCategories)
+}
@@ -0,0 +1,205 @@
+package webhooks
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mobilda/model"
+
+	"bitbucket.org/mobio/go-dbmanager"
+	"bitbucket.org/mobio/go-logger"
+)
+
+// Options of the dispatcher
+type Options struct {
+	// PollInterval is a pause between outbox polls when there is nothing to deliver
+	PollInterval time.Duration
+	BatchSize    int
+	Timeout      time.Duration
+	// MaxAttempts is a number of attempts after which a delivery goes to the dead-letter queue
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+}
+
+var DefaultOptions = Options{
+	PollInterval: 5 * time.Second,
+	BatchSize:    100,
+	Timeout:      10 * time.Second,
+	MaxAttempts:  10,
+	MinBackoff:   30 * time.Second,
+	MaxBackoff:   6 * time.Hour,
+}
+
+// Backoff returns a delay before the next attempt, doubled after every failed attempt
+func (o Options) Backoff(attempts int) time.Duration {
+	delay := o.MinBackoff
+	for i := 1; i < attempts && delay < o.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > o.MaxBackoff {
+		delay = o.MaxBackoff
+	}
+	return delay
+}
+
+// Dispatcher delivers pending outbox records to webhooks.
+// Deliveries are at-least-once and may be reordered by retries, subscribers dedupe and order them by seq.
+// A single dispatcher should run against the database.
+type Dispatcher struct {
+	db     *dbmanager.DbManager
+	log    *logger.Logger
+	client *http.Client
+	opts   Options
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+func NewDispatcher(db *dbmanager.DbManager, l *logger.Logger, opts Options) *Dispatcher {
+	return &Dispatcher{
+		db:     db,
+		log:    l,
+		client: &http.Client{Timeout: opts.Timeout},
+		opts:   opts,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+func (d *Dispatcher) Start() {
+	go d.loop()
+}
+
+// Stop waits for the current batch, undelivered records stay in the outbox
+func (d *Dispatcher) Stop() {
+	close(d.stop)
+	<-d.done
+}
+
+func (d *Dispatcher) loop() {
+	defer close(d.done)
+
+	for {
+		n, err := d.dispatch()
+		if err != nil {
+			d.log.WithField("component", "webhooks-dispatcher").Error(err)
+		}
+		if err == nil && n == d.opts.BatchSize {
+			select {
+			case <-d.stop:
+				return
+			default:
+				continue
+			}
+		}
+
+		select {
+		case <-d.stop:
+			return
+		case <-time.After(d.opts.PollInterval):
+		}
+	}
+}
+
+// dispatch delivers a batch of due records of active webhooks and returns the batch size
+func (d *Dispatcher) dispatch() (int, error) {
+	deliveries := []model.WebhookDelivery{}
+	err := d.db.Model(&deliveries).
+		Where("status = ?", model.DeliveryStatusPending).
+		Where("next_attempt_at <= ?", time.Now()).
+		Where("webhook_id IN (SELECT id FROM mobilda.webhook WHERE is_active)").
+		Order("id").
+		Limit(d.opts.BatchSize).
+		Select()
+	if err != nil || len(deliveries) == 0 {
+		return 0, err
+	}
+
+	ids := []interface{}{}
+	for _, delivery := range deliveries {
+		ids = append(ids, delivery.WebhookId)
+	}
+	hooks := []model.Webhook{}
+	if err := d.db.Model(&hooks).WhereIn("id IN (?)", ids...).Select(); err != nil {
+		return 0, err
+	}
+	byId := map[int]model.Webhook{}
+	for _, hook := range hooks {
+		byId[hook.Id] = hook
+	}
+
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		hook, ok := byId[delivery.WebhookId]
+		if !ok {
+			continue
+		}
+
+		d.result(delivery, d.deliver(hook, *delivery), time.Now())
+		_, err := d.db.Model(delivery).
+			Column("status", "attempts", "next_attempt_at", "last_error", "delivered_at").
+			Update()
+		if err != nil {
+			return i, err
+		}
+		if delivery.Status == model.DeliveryStatusDead {
+			d.log.WithField("component", "webhooks-dispatcher").
+				Warnf("Delivery %d of seq %d to webhook %d is dead: %s", delivery.Id, delivery.Seq, hook.Id, delivery.LastError)
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// deliver posts the payload signed with the webhook secret, any 2xx response is a success
+func (d *Dispatcher) deliver(hook model.Webhook, delivery model.WebhookDelivery) error {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequest("POST", hook.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.Id, 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// result updates the delivery after an attempt, failed deliveries are rescheduled
+// with a backoff until attempts are exceeded
+func (d *Dispatcher) result(delivery *model.WebhookDelivery, err error, now time.Time) {
+	delivery.Attempts++
+
+	if err == nil {
+		delivery.Status = model.DeliveryStatusDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= d.opts.MaxAttempts {
+		delivery.Status = model.DeliveryStatusDead
+		return
+	}
+	delivery.NextAttemptAt = now.Add(d.opts.Backoff(delivery.Attempts))
+}
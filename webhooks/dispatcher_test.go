@@ -0,0 +1,99 @@
+package webhooks
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"mobilda/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions_Backoff(t *testing.T) {
+	opts := Options{MinBackoff: time.Minute, MaxBackoff: 10 * time.Minute}
+
+	assert.Equal(t, time.Minute, opts.Backoff(1))
+	assert.Equal(t, 2*time.Minute, opts.Backoff(2))
+	assert.Equal(t, 8*time.Minute, opts.Backoff(4))
+	assert.Equal(t, 10*time.Minute, opts.Backoff(5))
+	assert.Equal(t, 10*time.Minute, opts.Backoff(100))
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	hook := model.Webhook{Id: 1, Secret: "secret"}
+	delivery := model.WebhookDelivery{Id: 7, Seq: 42, EventType: model.OfferChangeCreated, Payload: `{"seq":42}`}
+
+	var received *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+	hook.Url = srv.URL
+
+	d := NewDispatcher(nil, nil, DefaultOptions)
+	assert.NoError(t, d.deliver(hook, delivery))
+
+	assert.Equal(t, "POST", received.Method)
+	assert.Equal(t, delivery.Payload, string(body))
+	assert.Equal(t, "7", received.Header.Get(DeliveryHeader))
+	assert.Equal(t, model.OfferChangeCreated, received.Header.Get(EventHeader))
+
+	timestamp, err := strconv.ParseInt(received.Header.Get(TimestampHeader), 10, 64)
+	assert.NoError(t, err)
+	assert.True(t, Verify("secret", timestamp, body, received.Header.Get(SignatureHeader)))
+	assert.False(t, Verify("other", timestamp, body, received.Header.Get(SignatureHeader)))
+	assert.False(t, Verify("secret", timestamp+1, body, received.Header.Get(SignatureHeader)))
+}
+
+func TestDispatcher_DeliverFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(503)
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(nil, nil, DefaultOptions)
+	err := d.deliver(model.Webhook{Url: srv.URL}, model.WebhookDelivery{Payload: "{}"})
+	assert.EqualError(t, err, "Webhook responded with status 503")
+
+	srv.Close()
+	assert.Error(t, d.deliver(model.Webhook{Url: srv.URL}, model.WebhookDelivery{Payload: "{}"}))
+}
+
+func TestDispatcher_Result(t *testing.T) {
+	d := NewDispatcher(nil, nil, Options{MaxAttempts: 3, MinBackoff: time.Minute, MaxBackoff: time.Hour})
+	now := time.Now()
+	delivery := &model.WebhookDelivery{Status: model.DeliveryStatusPending}
+
+	d.result(delivery, errors.New("timeout"), now)
+	assert.Equal(t, model.DeliveryStatusPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, now.Add(time.Minute), delivery.NextAttemptAt)
+	assert.Equal(t, "timeout", delivery.LastError)
+
+	d.result(delivery, errors.New("timeout"), now)
+	assert.Equal(t, now.Add(2*time.Minute), delivery.NextAttemptAt)
+
+	d.result(delivery, errors.New("timeout"), now)
+	assert.Equal(t, model.DeliveryStatusDead, delivery.Status)
+
+	ok := &model.WebhookDelivery{Status: model.DeliveryStatusPending, LastError: "timeout"}
+	d.result(ok, nil, now)
+	assert.Equal(t, model.DeliveryStatusDelivered, ok.Status)
+	assert.Equal(t, now, *ok.DeliveredAt)
+	assert.Empty(t, ok.LastError)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(model.Webhook{Url: "https://example.com/hook"}))
+	assert.NoError(t, Validate(model.Webhook{Url: "http://example.com", Types: []string{model.OfferChangeStopped}}))
+	assert.Error(t, Validate(model.Webhook{Url: "example.com/hook"}))
+	assert.Error(t, Validate(model.Webhook{Url: "ftp://example.com"}))
+	assert.Error(t, Validate(model.Webhook{Url: "https://example.com", Types: []string{"deleted"}}))
+}
@@ -0,0 +1,81 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"mobilda/errors"
+	"mobilda/events"
+	"mobilda/model"
+
+	"gopkg.in/pg.v5"
+)
+
+// Filter returns the events filter of the webhook
+func Filter(hook model.Webhook) events.Filter {
+	return events.Filter{
+		Accounts:  hook.Accounts,
+		Countries: hook.Countries,
+		Types:     hook.Types,
+	}
+}
+
+// Validate checks webhook url and event types
+func Validate(hook model.Webhook) error {
+	u, err := url.Parse(hook.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.ErrWebhookUrlInvalid
+	}
+	for _, t := range hook.Types {
+		switch t {
+		case model.OfferChangeCreated, model.OfferChangeUpdated, model.OfferChangeStopped, model.OfferChangeReactivated:
+		default:
+			return errors.ErrWebhookTypeInvalid
+		}
+	}
+	return nil
+}
+
+// Enqueue writes deliveries of the events for every active webhook matching them.
+// It is called in the transaction writing the offers, so deliveries are committed together with the change.
+func Enqueue(tx *pg.Tx, offerEvents []model.OfferEvent) error {
+	if len(offerEvents) == 0 {
+		return nil
+	}
+
+	hooks := []model.Webhook{}
+	if err := tx.Model(&hooks).Where("is_active = ?", true).Select(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	deliveries := []model.WebhookDelivery{}
+	for _, hook := range hooks {
+		filter := Filter(hook)
+		for _, e := range offerEvents {
+			if !filter.Match(e) {
+				continue
+			}
+			payload, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			deliveries = append(deliveries, model.WebhookDelivery{
+				WebhookId:     hook.Id,
+				Seq:           e.Seq,
+				EventType:     e.ChangeType,
+				Payload:       string(payload),
+				Status:        model.DeliveryStatusPending,
+				NextAttemptAt: now,
+				CreatedAt:     now,
+			})
+		}
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+	_, err := tx.Model(&deliveries).Insert()
+	return err
+}